@@ -0,0 +1,68 @@
+// Command wallet-migrate moves wallet identities between backends, e.g. from the plaintext
+// ./wallet directory into the encrypted file store or Postgres:
+//
+//	WALLET_MASTER_KEY=$(openssl rand -base64 32) go run ./cmd/wallet-migrate -from file -to postgres -remove-source
+package main
+
+import (
+	"backend/internal/db"
+	"backend/internal/fabric"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+func main() {
+	from := flag.String("from", fabric.WalletTypeFile, "source backend: file, encrypted or postgres")
+	to := flag.String("to", fabric.WalletTypeEncrypted, "target backend: file, encrypted or postgres")
+	fromPath := flag.String("from-path", envOr("WALLET_PATH", "./wallet"), "source wallet directory (file/encrypted)")
+	toPath := flag.String("to-path", envOr("WALLET_PATH", "./wallet"), "target wallet directory (file/encrypted)")
+	orgs := flag.String("orgs", "Org1MSP,Org2MSP", "comma-separated MSP IDs to migrate")
+	removeSource := flag.Bool("remove-source", false, "delete each identity from the source once copied")
+	flag.Parse()
+
+	if *from == *to && *fromPath == *toPath {
+		log.Fatal("Source and target wallets are the same")
+	}
+	// file <-> encrypted in place rewrites the key file of the same directory, which -remove-source would delete
+	if *from != fabric.WalletTypePostgres && *to != fabric.WalletTypePostgres && *fromPath == *toPath && *removeSource {
+		log.Fatal("-remove-source cannot be used when migrating a wallet directory in place")
+	}
+
+	// The master key is shared: it decrypts an encrypted source and encrypts the target
+	masterKey := os.Getenv("WALLET_MASTER_KEY")
+
+	var database *gorm.DB
+	if *from == fabric.WalletTypePostgres || *to == fabric.WalletTypePostgres {
+		var err error
+		database, err = db.InitDB(envOr("DB_HOST", "localhost"), envOr("DB_PORT", "5432"), "admin", "adminpw", "ownership_registry")
+		if err != nil {
+			log.Fatal("Failed to initialize database:", err)
+		}
+	}
+
+	source, err := fabric.NewWallet(fabric.WalletConfig{Type: *from, Path: *fromPath, MasterKey: masterKey}, database)
+	if err != nil {
+		log.Fatalf("Failed to open source wallet: %v", err)
+	}
+	target, err := fabric.NewWallet(fabric.WalletConfig{Type: *to, Path: *toPath, MasterKey: masterKey}, database)
+	if err != nil {
+		log.Fatalf("Failed to open target wallet: %v", err)
+	}
+
+	migrated, err := fabric.MigrateWallet(source, target, strings.Split(*orgs, ","), *removeSource)
+	if err != nil {
+		log.Fatalf("Migration stopped after %d identities: %v", migrated, err)
+	}
+	log.Printf("Migrated %d identities from %s to %s", migrated, *from, *to)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
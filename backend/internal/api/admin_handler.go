@@ -4,24 +4,27 @@ import (
 	"backend/internal/fabric"
 	"backend/internal/models"
 	"encoding/json"
+	"errors"
 	"log"
-	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"google.golang.org/grpc"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"gorm.io/gorm"
-
-	"fmt"
 )
 
 type AdminHandler struct {
-	CAConfigs  []fabric.CAConfig
-	WalletPath string
-	Config     fabric.Config
-	Conn       interface{} // *grpc.ClientConn
-	DB         *gorm.DB
+	CAConfigs    []fabric.CAConfig
+	Gateways     *fabric.GatewayPool
+	Transactions *fabric.TransactionTracker
+	Peers        *fabric.PeerNetwork
+	DB           *gorm.DB
+	Listener     *fabric.EventListener
+	Revoker      *fabric.Revoker
+	Expiry       *fabric.ExpiryMonitor
 }
 
 type NetworkStats struct {
@@ -72,43 +75,38 @@ func (h *AdminHandler) GetUsers(c *fiber.Ctx) error {
 		dbMap[u.Username] = u
 	}
 
-	reName := regexp.MustCompile(`Name: ([^,]+)`)
-	reType := regexp.MustCompile(`Type: ([^,]+)`)
-
 	for _, caCfg := range h.CAConfigs {
-		raw, err := fabric.ListIdentities(caCfg)
+		identities, err := fabric.ListIdentities(caCfg)
 		if err != nil {
 			log.Printf("Warning: failed to list identities from %s: %v", caCfg.MSPID, err)
 			continue
 		}
 
-		lines := strings.Split(strings.TrimSpace(raw), "\n")
-		for _, line := range lines {
-			nameMatch := reName.FindStringSubmatch(line)
-			typeMatch := reType.FindStringSubmatch(line)
+		for _, identity := range identities {
+			status := "ACTIVE"
+			email := identity.ID + "@example.org"
+			role := identity.Attribute(fabric.RoleAttribute)
 
-			if len(nameMatch) > 1 && len(typeMatch) > 1 {
-				name := nameMatch[1]
-				status := "ACTIVE"
-				email := name + "@example.org"
-				role := "user"
-
-				if dbUser, exists := dbMap[name]; exists {
-					status = dbUser.Status
-					email = dbUser.Email
+			if dbUser, exists := dbMap[identity.ID]; exists {
+				status = dbUser.Status
+				email = dbUser.Email
+				if role == "" {
 					role = dbUser.Role
 				}
-
-				allIdentities = append(allIdentities, IdentityInfo{
-					Name:     name,
-					Type:     typeMatch[1],
-					DBStatus: "Synced",
-					Status:   status,
-					Email:    email,
-					Role:     role,
-					Org:      caCfg.MSPID,
-				})
 			}
+			if role == "" {
+				role = fabric.RoleUser
+			}
+
+			allIdentities = append(allIdentities, IdentityInfo{
+				Name:     identity.ID,
+				Type:     identity.Type,
+				DBStatus: "Synced",
+				Status:   status,
+				Email:    email,
+				Role:     role,
+				Org:      caCfg.MSPID,
+			})
 		}
 	}
 
@@ -154,10 +152,46 @@ func (h *AdminHandler) UpdateUserStatus(c *fiber.Ctx) error {
 			Role:     "user",
 		}
 	}
-	if req.Status != "" {
+	if req.Status != "" && req.Status != user.Status {
+		if isRevokedStatus(user.Status) {
+			return c.Status(409).JSON(fiber.Map{"error": "The identity of a " + strings.ToLower(user.Status) + " user has been revoked and cannot be reactivated"})
+		}
+		if isRevokedStatus(req.Status) {
+			caCfg, ok := h.caConfig(user.Org)
+			if !ok {
+				return c.Status(400).JSON(fiber.Map{"error": "Unknown organization " + user.Org})
+			}
+			if err := h.Revoker.RevokeUser(caCfg, username, "privilegewithdrawn"); err != nil {
+				if !errors.Is(err, fabric.ErrCRLNotPublished) {
+					return c.Status(502).JSON(fiber.Map{"error": "Failed to revoke identity: " + err.Error()})
+				}
+				// The CA no longer accepts the identity; record the ban and let the admin retry the CRL
+				log.Printf("Warning: %v", err)
+				user.Status = req.Status
+				if err := h.DB.Save(&user).Error; err != nil {
+					return c.Status(500).JSON(fiber.Map{"error": "Failed to update user status"})
+				}
+				return c.Status(202).JSON(fiber.Map{
+					"message": "Identity revoked at the CA, but the channel CRL update failed; retry via POST /admin/crl/" + user.Org,
+					"status":  user.Status,
+					"role":    user.Role,
+				})
+			}
+		}
 		user.Status = req.Status
 	}
-	if req.Role != "" {
+	if req.Role != "" && req.Role != user.Role {
+		if !fabric.ValidRole(req.Role) {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid role"})
+		}
+		// The CA attribute is the source of truth for the chaincode; only mirror it in the DB once it is set
+		caCfg, ok := h.caConfig(user.Org)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "Unknown organization " + user.Org})
+		}
+		if err := fabric.SetUserRole(caCfg, username, req.Role); err != nil {
+			return c.Status(502).JSON(fiber.Map{"error": "Failed to update role on CA: " + err.Error()})
+		}
 		user.Role = req.Role
 	}
 
@@ -168,8 +202,35 @@ func (h *AdminHandler) UpdateUserStatus(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "User updated successfully", "status": user.Status, "role": user.Role})
 }
 
+// PublishCRL re-publishes an organization's current CA CRL to the channel, e.g. after a failed config update
+func (h *AdminHandler) PublishCRL(c *fiber.Ctx) error {
+	caCfg, ok := h.caConfig(c.Params("org"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Unknown organization " + c.Params("org")})
+	}
+	if err := h.Revoker.SyncCRL(caCfg); err != nil {
+		return c.Status(502).JSON(fiber.Map{"error": "Failed to publish CRL: " + err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "CRL published for " + caCfg.MSPID})
+}
+
+// isRevokedStatus reports whether an account status implies its certificates are revoked
+func isRevokedStatus(status string) bool {
+	return status == "BANNED" || status == "DELETED"
+}
+
+// caConfig returns the CA configuration for an MSP ID
+func (h *AdminHandler) caConfig(mspid string) (fabric.CAConfig, bool) {
+	for _, cfg := range h.CAConfigs {
+		if cfg.MSPID == mspid {
+			return cfg, true
+		}
+	}
+	return fabric.CAConfig{}, false
+}
+
 func (h *AdminHandler) UpdateAssetStatus(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id := utils.CopyString(c.Params("id"))
 	type StatusReq struct {
 		Status string `json:"status"`
 	}
@@ -179,40 +240,89 @@ func (h *AdminHandler) UpdateAssetStatus(c *fiber.Ctx) error {
 	}
 
 	// 1. Update on Blockchain
-	adminUsername := c.Locals("user").(string)
-	adminOrg := c.Locals("org").(string)
-	identity, sign, err := fabric.GetIdentity(adminUsername, adminOrg, h.WalletPath)
+	gw, contract, err := CallerContract(c, h.Gateways)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("Admin identity (%s) not found in wallet: %v", adminUsername, err)})
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	defer gw.Close()
 
-	grpcConn, ok := h.Conn.(*grpc.ClientConn)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "Invalid connection"})
+	// The asset's key-level policy names its owner's org (and a pending recipient's)
+	var parties models.Asset
+	endorsingOrgs := fabric.AssetEndorsers(contract, id, c.Locals("org").(string), &parties)
+
+	// Rejected transitions come back as CONFLICT or INVALID_ARGUMENT from the chaincode
+	responded, _, err := SubmitAsset(c, h.Transactions, gw, Submission{
+		Function: "UpdateAssetStatus",
+		AssetID:  id,
+		Options: []client.ProposalOption{
+			client.WithArguments(id, req.Status),
+			client.WithEndorsingOrganizations(endorsingOrgs...),
+		},
+		OnCommit: func(string) {
+			// 2. Update in DB (if exists)
+			var asset models.Asset
+			if err := h.DB.Where("id = ?", id).First(&asset).Error; err == nil {
+				asset.Status = req.Status
+				h.DB.Save(&asset)
+			}
+		},
+	})
+	if responded {
+		return err
 	}
 
-	gw, err := fabric.CreateGateway(grpcConn, identity, sign)
+	return c.JSON(fiber.Map{"message": "Asset status updated: " + req.Status, "status": req.Status})
+}
+
+// MigrateAssetKeys moves assets still stored under bare ledger keys to their namespaced
+// composite keys, one batch per transaction until none remain. Each batch is endorsed by every
+// org, since the migrated assets' key-level policies may name any of them. A failed run can
+// simply be repeated; batches that committed stay migrated.
+func (h *AdminHandler) MigrateAssetKeys(c *fiber.Ctx) error {
+	batchSize := c.QueryInt("batch", 100)
+	if batchSize <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "batch must be greater than zero"})
+	}
+
+	gw, contract, err := CallerContract(c, h.Gateways)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Gateway creation failed"})
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
 	}
 	defer gw.Close()
 
-	network := gw.GetNetwork(h.Config.ChannelName)
-	contract := network.GetContract(h.Config.ChaincodeName)
-
-	_, err = contract.SubmitTransaction("UpdateAssetStatus", id, req.Status)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Blockchain update failed: " + err.Error()})
+	var orgs []string
+	for _, cfg := range h.CAConfigs {
+		orgs = append(orgs, cfg.MSPID)
 	}
 
-	// 2. Update in DB (if exists)
-	var asset models.Asset
-	if err := h.DB.Where("id = ?", id).First(&asset).Error; err == nil {
-		asset.Status = req.Status
-		h.DB.Save(&asset)
+	migrated := []string{}
+	for {
+		_, result, err := fabric.SubmitTransaction(contract, "MigrateAssetKeys",
+			client.WithArguments(strconv.Itoa(batchSize)),
+			client.WithEndorsingOrganizations(orgs...))
+		if err != nil {
+			log.Printf("Asset key migration stopped after %d assets: %v", len(migrated), err)
+			return ContractError(c, err)
+		}
+
+		var batch struct {
+			Migrated  []string `json:"migrated"`
+			Remaining bool     `json:"remaining"`
+		}
+		if err := json.Unmarshal(result, &batch); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to parse migration result"})
+		}
+		migrated = append(migrated, batch.Migrated...)
+		if !batch.Remaining {
+			break
+		}
 	}
 
-	return c.JSON(fiber.Map{"message": "Asset status updated: " + req.Status, "status": req.Status})
+	return c.JSON(fiber.Map{
+		"message":  "Asset key migration complete",
+		"count":    len(migrated),
+		"migrated": migrated,
+	})
 }
 
 func (h *AdminHandler) GetAdminAssets(c *fiber.Ctx) error {
@@ -220,7 +330,7 @@ func (h *AdminHandler) GetAdminAssets(c *fiber.Ctx) error {
 
 	if source == "database" {
 		var assets []models.Asset
-		if err := h.DB.Find(&assets).Error; err != nil {
+		if err := fabric.OrderedAttachments(h.DB).Find(&assets).Error; err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
 		}
 		return c.JSON(fiber.Map{
@@ -229,94 +339,43 @@ func (h *AdminHandler) GetAdminAssets(c *fiber.Ctx) error {
 		})
 	}
 
-	// Blockchain logic: Admin only
-	adminUsername := c.Locals("user").(string)
-	adminOrg := c.Locals("org").(string)
-	id, sign, err := fabric.GetIdentity(adminUsername, adminOrg, h.WalletPath)
+	query, err := ParseAssetQuery(c)
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": fmt.Sprintf("Admin identity (%s) not found in wallet", adminUsername)})
-	}
-
-	// Type assert Conn
-	grpcConn, ok := h.Conn.(*grpc.ClientConn)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "Invalid gRPC connection type"})
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	gw, err := fabric.CreateGateway(grpcConn, id, sign)
+	// Blockchain logic: Admin only
+	gw, contract, err := CallerContract(c, h.Gateways)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
 	}
 	defer gw.Close()
 
-	network := gw.GetNetwork(h.Config.ChannelName)
-	contract := network.GetContract(h.Config.ChaincodeName)
-
-	result, err := contract.EvaluateTransaction("GetAllAssets")
+	page, err := fabric.QueryAssets(contract, query)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	var ledgerValues []models.LedgerValue
-	if err := json.Unmarshal(result, &ledgerValues); err != nil {
-		// Fallback for legacy data that might be flat
-		var flatAssets []interface{}
-		if err := json.Unmarshal(result, &flatAssets); err == nil {
-			return c.JSON(fiber.Map{
-				"source": "blockchain",
-				"assets": flatAssets,
-			})
-		}
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to parse assets"})
+		return ContractError(c, err)
 	}
 
 	// Flatten for frontend compatibility
-	var assets []models.Asset
-	for _, val := range ledgerValues {
-		asset := val.Asset
-		asset.Action = val.Audit.Action
-		asset.LastUpdatedBy = val.Audit.Actor
-		if val.Audit.Timestamp != "" {
-			t, err := time.Parse(time.RFC3339, val.Audit.Timestamp)
-			if err == nil {
-				asset.LastUpdatedAt = t
-			}
-		}
-		assets = append(assets, asset)
-	}
-
 	return c.JSON(fiber.Map{
-		"source": "blockchain",
-		"assets": assets,
+		"source":        "blockchain",
+		"assets":        FlattenPage(page),
+		"bookmark":      page.Bookmark,
+		"fetched_count": page.FetchedRecordsCount,
 	})
 }
 
 func (h *AdminHandler) Sync(c *fiber.Ctx) error {
 	// 1. Fetch all assets from Blockchain
-	adminUsername := c.Locals("user").(string)
-	adminOrg := c.Locals("org").(string)
-	id, sign, err := fabric.GetIdentity(adminUsername, adminOrg, h.WalletPath)
-	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": fmt.Sprintf("Admin identity (%s) not found in wallet", adminUsername)})
-	}
-
-	grpcConn, ok := h.Conn.(*grpc.ClientConn)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "Invalid gRPC connection"})
-	}
-
-	gw, err := fabric.CreateGateway(grpcConn, id, sign)
+	gw, contract, err := CallerContract(c, h.Gateways)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
 	}
 	defer gw.Close()
 
-	network := gw.GetNetwork(h.Config.ChannelName)
-	contract := network.GetContract(h.Config.ChaincodeName)
-
 	result, err := contract.EvaluateTransaction("GetAllAssets")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return ContractError(c, err)
 	}
 
 	var ledgerValues []models.LedgerValue
@@ -343,7 +402,7 @@ func (h *AdminHandler) Sync(c *fiber.Ctx) error {
 			}
 		}
 
-		if err := h.DB.Save(&asset).Error; err != nil {
+		if err := fabric.SaveAsset(h.DB, &asset); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": "Failed to sync asset to DB: " + err.Error()})
 		}
 	}
@@ -353,3 +412,57 @@ func (h *AdminHandler) Sync(c *fiber.Ctx) error {
 		"count":   len(ledgerValues),
 	})
 }
+
+// GetListenerStatus reports the event listener's connection state and checkpoint lag
+func (h *AdminHandler) GetListenerStatus(c *fiber.Ctx) error {
+	if h.Listener == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Event listener not configured"})
+	}
+
+	status := h.Listener.Status(c.Context())
+	if !status.Healthy() {
+		return c.Status(503).JSON(status)
+	}
+	return c.JSON(status)
+}
+
+// GetPeerHealth reports the health of every peer in the connection profile
+func (h *AdminHandler) GetPeerHealth(c *fiber.Ctx) error {
+	peers := h.Peers.Health()
+	for _, peer := range peers {
+		if !peer.Healthy {
+			return c.Status(503).JSON(fiber.Map{"peers": peers})
+		}
+	}
+	return c.JSON(fiber.Map{"peers": peers})
+}
+
+// GetExpiringIdentities lists wallet identities whose certificates expire within the monitor's window
+func (h *AdminHandler) GetExpiringIdentities(c *fiber.Ctx) error {
+	if h.Expiry == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Certificate expiry monitor not configured"})
+	}
+
+	if c.Query("rescan") == "true" {
+		h.Expiry.Scan()
+	}
+	identities, lastScan := h.Expiry.Expiring()
+	return c.JSON(fiber.Map{
+		"window":     h.Expiry.Window().String(),
+		"last_scan":  lastScan,
+		"identities": identities,
+	})
+}
+
+// ReenrollIdentity renews a wallet identity's certificate through the CA's /reenroll API
+func (h *AdminHandler) ReenrollIdentity(c *fiber.Ctx) error {
+	if h.Expiry == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Certificate expiry monitor not configured"})
+	}
+
+	renewed, err := h.Expiry.Renew(c.Params("org"), c.Params("username"))
+	if err != nil {
+		return c.Status(502).JSON(fiber.Map{"error": "Failed to re-enroll identity: " + err.Error()})
+	}
+	return c.JSON(renewed)
+}
@@ -4,6 +4,7 @@ import (
 	"backend/internal/auth"
 	"backend/internal/fabric"
 	"backend/internal/models"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -18,6 +19,7 @@ import (
 type AuthHandler struct {
 	CAConfigs map[string]fabric.CAConfig
 	DB        *gorm.DB
+	Revoker   *fabric.Revoker
 }
 
 // Login handles user authentication (Enrollment + JWT)
@@ -104,6 +106,29 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		role = "admin" // Override safety for admin accounts
 	}
 
+	// The certificate's role attribute is what the chaincode enforces, so it wins over the DB.
+	// Identities registered before roles were attributes get the DB role stamped on and are re-enrolled.
+	certRole, err := fabric.GetIdentityRole(req.Username, finalCfg.MSPID, finalCfg.Wallet)
+	if err != nil {
+		log.Printf("Failed to read role attribute for %s (%s): %v", req.Username, finalCfg.MSPID, err)
+	}
+	if certRole == "" && err == nil && fabric.ValidRole(role) {
+		if err := fabric.SetUserRole(finalCfg, req.Username, role); err != nil {
+			log.Printf("Failed to backfill role attribute for %s (%s): %v", req.Username, finalCfg.MSPID, err)
+		} else if err := fabric.EnrollUser(finalCfg, req.Username, req.Password); err != nil {
+			log.Printf("Re-enroll after role backfill failed for %s (%s): %v", req.Username, finalCfg.MSPID, err)
+		} else {
+			certRole = role
+		}
+	}
+	if certRole != "" {
+		role = certRole
+	}
+	if user.ID != 0 && user.Role != role {
+		user.Role = role
+		h.DB.Save(&user)
+	}
+
 	// 4. Finalize Response Org
 	orgResp := user.Org
 	if req.Username == "admin" {
@@ -238,7 +263,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// Call Fabric CA Registration
-	resp, err := fabric.RegisterUser(cfg, req.Username, req.Password)
+	resp, err := fabric.RegisterUser(cfg, req.Username, req.Password, fabric.RoleUser)
 	if err != nil {
 		log.Printf("Register failed for %s on %s: %v", req.Username, org, err)
 		return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("Registration failed: %v", err)})
@@ -258,7 +283,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		Username: req.Username,
 		Email:    userEmail,
 		Org:      org,
-		Role:     "user",
+		Role:     fabric.RoleUser,
 		Status:   "PENDING", // Wait for Admin Approval
 	}
 	if err := h.DB.Create(&dbUser).Error; err != nil {
@@ -291,7 +316,19 @@ func (h *AuthHandler) DeleteAccount(c *fiber.Ctx) error {
 		})
 	}
 
-	// 2. Soft Delete in Database
+	// 2. Revoke the enrollment certificate so it can no longer sign transactions at the peer
+	caCfg, ok := h.CAConfigs[org]
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Unknown organization " + org})
+	}
+	if err := h.Revoker.RevokeUser(caCfg, username, "cessationofoperation"); err != nil {
+		if !errors.Is(err, fabric.ErrCRLNotPublished) {
+			return c.Status(502).JSON(fiber.Map{"error": "Failed to revoke identity: " + err.Error()})
+		}
+		log.Printf("Warning: %v", err)
+	}
+
+	// 3. Soft Delete in Database
 	if err := h.DB.Model(&models.User{}).Where("username = ? AND org = ?", username, org).Update("status", "DELETED").Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to deactivate account"})
 	}
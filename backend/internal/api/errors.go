@@ -0,0 +1,39 @@
+package api
+
+import (
+	"backend/internal/fabric"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// contractErrorStatus maps error codes to HTTP statuses; unknown codes are server errors
+var contractErrorStatus = map[string]int{
+	fabric.CodeInvalidArgument:  fiber.StatusBadRequest,
+	fabric.CodePermissionDenied: fiber.StatusForbidden,
+	fabric.CodeNotFound:         fiber.StatusNotFound,
+	fabric.CodeConflict:         fiber.StatusConflict,
+	fabric.CodeAlreadyExists:    fiber.StatusConflict,
+	fabric.CodeUnavailable:      fiber.StatusServiceUnavailable,
+	fabric.CodeTimeout:          fiber.StatusGatewayTimeout,
+}
+
+// ContractError writes a failed Evaluate or Submit as
+// {"error": message, "code": CODE, "tx_id": ..., "details": [...]} with the matching status.
+// Only the classified message reaches the client; peer details are kept for server errors.
+func ContractError(c *fiber.Ctx, err error) error {
+	parsed := fabric.ParseContractError(err)
+
+	statusCode, ok := contractErrorStatus[parsed.Code]
+	if !ok {
+		statusCode = fiber.StatusInternalServerError
+	}
+
+	body := fiber.Map{"error": parsed.Message, "code": parsed.Code}
+	if parsed.TxID != "" {
+		body["tx_id"] = parsed.TxID
+	}
+	if statusCode >= fiber.StatusInternalServerError && len(parsed.Details) > 0 {
+		body["details"] = parsed.Details
+	}
+	return c.Status(statusCode).JSON(body)
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"backend/internal/fabric"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// CallerContract leases the gateway of the authenticated caller (the user/org locals set by
+// auth.Middleware) and returns the chaincode contract on it. Close the lease when done.
+func CallerContract(c *fiber.Ctx, gateways *fabric.GatewayPool) (*fabric.GatewayLease, *client.Contract, error) {
+	username := c.Locals("user").(string)
+	org := c.Locals("org").(string)
+
+	lease, contract, err := gateways.Contract(org, username)
+	if err != nil {
+		return nil, nil, fmt.Errorf("identity not found for user %s (%s): %v", username, org, err)
+	}
+	return lease, contract, nil
+}
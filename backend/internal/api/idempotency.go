@@ -0,0 +1,230 @@
+package api
+
+import (
+	"backend/internal/fabric"
+	"backend/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyHeader carries the client-chosen key of a request that may be retried
+const IdempotencyHeader = "Idempotency-Key"
+
+const (
+	// idempotencyTTL is how long a key and its response are kept
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyLease is how long a first attempt may stay unfinished (e.g. the backend
+	// restarted mid-request) before a retry with the same request takes the key over
+	idempotencyLease = 2 * time.Minute
+)
+
+// Idempotent makes a route safe to retry: a request repeating one of the caller's
+// Idempotency-Key values gets the stored status, body and transaction ID of the first one
+// instead of running again. A key is bound to a hash of the method, path, query and body, so
+// reusing it for a different request is rejected with 422. Responses that depend on the moment
+// rather than the request (5xx, 401, 403, 408, 429) are not stored, so those requests can be
+// retried; but when the transaction was submitted and only its commit status is missing, the key
+// stays bound to the transaction and retries are answered from its tracked status.
+// Must run after auth.Middleware.
+func Idempotent(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(400).JSON(fiber.Map{"error": IdempotencyHeader + " must be at most 255 characters"})
+		}
+
+		record := models.IdempotencyKey{
+			Key:         key,
+			Submitter:   fmt.Sprintf("%s::%s", c.Locals("org").(string), c.Locals("user").(string)),
+			RequestHash: requestHash(c),
+		}
+		claimed, err := claimIdempotencyKey(db, &record)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+		}
+
+		if !claimed && record.RequestHash != requestHash(c) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": IdempotencyHeader + " was already used for a different request",
+				"code":  "IDEMPOTENCY_KEY_REUSED",
+			})
+		}
+		if !claimed && record.StatusCode == 0 && record.TxID != "" {
+			claimed, err = resolveSubmitted(c, db, &record)
+			if err != nil || !claimed {
+				return err
+			}
+		}
+		if !claimed {
+			if record.StatusCode == 0 {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A request with this " + IdempotencyHeader + " is still in progress",
+					"code":  "REQUEST_IN_PROGRESS",
+				})
+			}
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, record.ContentType)
+			return c.Status(record.StatusCode).Send(record.ResponseBody)
+		}
+
+		if err := c.Next(); err != nil {
+			releaseIdempotencyKey(db, &record)
+			return err
+		}
+
+		statusCode := c.Response().StatusCode()
+		txID, _ := c.Locals(TxIDLocal).(string)
+		if !replayable(statusCode) {
+			if txID != "" && statusCode >= fiber.StatusInternalServerError {
+				// Submitted but the commit status is unknown: a retry must not submit again
+				err := db.Model(&record).Update("tx_id", txID).Error
+				if err == nil {
+					return nil
+				}
+				log.Printf("Failed to bind %s %s to tx %s: %v", IdempotencyHeader, record.Key, txID, err)
+			}
+			releaseIdempotencyKey(db, &record)
+			return nil
+		}
+		storeIdempotentResponse(db, &record, txID, statusCode, string(c.Response().Header.ContentType()), c.Response().Body())
+		return nil
+	}
+}
+
+// resolveSubmitted answers a retry whose first attempt submitted record.TxID without learning
+// its commit status. A committed transaction is answered like an async submit that completed,
+// and the answer is stored for later retries; a transaction that failed or was invalidated
+// hands the key back to the retry (true is returned); one still pending is in progress.
+func resolveSubmitted(c *fiber.Ctx, db *gorm.DB, record *models.IdempotencyKey) (bool, error) {
+	var transaction models.Transaction
+	if err := db.Where("tx_id = ?", record.TxID).First(&transaction).Error; err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+	}
+
+	switch transaction.Status {
+	case fabric.TxCommitted:
+		if err := c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"tx_id":      transaction.TxID,
+			"asset_id":   transaction.AssetID,
+			"status":     transaction.Status,
+			"status_url": "/tx/" + transaction.TxID,
+		}); err != nil {
+			return false, err
+		}
+		storeIdempotentResponse(db, record, transaction.TxID, fiber.StatusOK, string(c.Response().Header.ContentType()), c.Response().Body())
+		return false, nil
+	case fabric.TxInvalid, fabric.TxFailed:
+		// Conditional on tx_id, so only one of several concurrent retries runs the request again
+		result := db.Model(&models.IdempotencyKey{}).
+			Where("key = ? AND submitter = ? AND tx_id = ?", record.Key, record.Submitter, record.TxID).
+			Updates(map[string]interface{}{"tx_id": "", "updated_at": time.Now()})
+		if result.Error != nil {
+			return false, c.Status(500).JSON(fiber.Map{"error": "Database error: " + result.Error.Error()})
+		}
+		if result.RowsAffected == 1 {
+			record.TxID = ""
+			return true, nil
+		}
+	}
+	return false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":      "A request with this " + IdempotencyHeader + " is still in progress",
+		"code":       "REQUEST_IN_PROGRESS",
+		"tx_id":      transaction.TxID,
+		"status_url": "/tx/" + transaction.TxID,
+	})
+}
+
+// storeIdempotentResponse records the response a key's retries are answered with
+func storeIdempotentResponse(db *gorm.DB, record *models.IdempotencyKey, txID string, statusCode int, contentType string, body []byte) {
+	err := db.Model(record).Updates(map[string]interface{}{
+		"tx_id":         txID,
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": append([]byte(nil), body...),
+	}).Error
+	if err != nil {
+		log.Printf("Failed to store response for %s %s: %v", IdempotencyHeader, record.Key, err)
+	}
+}
+
+// claimIdempotencyKey inserts record unless the caller already used its key. Otherwise record is
+// replaced by the stored row and false is returned, except for expired keys and abandoned first
+// attempts of the same request, which are claimed again.
+func claimIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("key = ? AND submitter = ?", record.Key, record.Submitter).First(&existing).Error; err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	expired := existing.CreatedAt.Before(now.Add(-idempotencyTTL))
+	// A first attempt bound to a submitted transaction is resolved from its status instead
+	abandoned := existing.StatusCode == 0 && existing.TxID == "" && existing.RequestHash == record.RequestHash &&
+		existing.UpdatedAt.Before(now.Add(-idempotencyLease))
+	if expired || abandoned {
+		// Conditional on updated_at, so only one of several concurrent retries wins
+		result := db.Model(&models.IdempotencyKey{}).
+			Where("key = ? AND submitter = ? AND updated_at = ?", existing.Key, existing.Submitter, existing.UpdatedAt).
+			Updates(map[string]interface{}{
+				"request_hash":  record.RequestHash,
+				"tx_id":         "",
+				"status_code":   0,
+				"content_type":  "",
+				"response_body": nil,
+				"created_at":    now,
+				"updated_at":    now,
+			})
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return true, nil
+		}
+		if err := db.Where("key = ? AND submitter = ?", record.Key, record.Submitter).First(&existing).Error; err != nil {
+			return false, err
+		}
+	}
+
+	*record = existing
+	return false, nil
+}
+
+// releaseIdempotencyKey forgets a key whose request failed in a way worth retrying
+func releaseIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey) {
+	if err := db.Where("key = ? AND submitter = ?", record.Key, record.Submitter).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		log.Printf("Failed to release %s %s: %v", IdempotencyHeader, record.Key, err)
+	}
+}
+
+func replayable(statusCode int) bool {
+	switch statusCode {
+	case fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusRequestTimeout, fiber.StatusTooManyRequests:
+		return false
+	}
+	return statusCode < 500
+}
+
+func requestHash(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + "\n" + c.Path() + "?" + string(c.Request().URI().QueryString()) + "\n"))
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParseByteSize reads a size such as "10MB", "5GiB" or a plain number of bytes. Decimal and
+// binary suffixes are both treated as powers of 1024, as operators usually mean them.
+func ParseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{
+		{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"TIB", 1 << 40},
+		{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40},
+		{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// LimitBody rejects request bodies over limit bytes with 413. With StreamRequestBody enabled
+// fasthttp hands larger bodies to the handler as a stream instead of refusing them, so every route
+// not under one of the streamed prefixes is checked here before its body is read.
+func LimitBody(limit int64, streamedPrefixes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, prefix := range streamedPrefixes {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+
+		length := c.Request().Header.ContentLength()
+		if int64(length) > limit {
+			return c.Status(413).JSON(fiber.Map{"error": "Request body too large"})
+		}
+
+		// Chunked bodies have no declared length; read up to the limit to find out
+		if length < 0 {
+			if stream := c.Context().RequestBodyStream(); stream != nil {
+				body, err := io.ReadAll(io.LimitReader(stream, limit+1))
+				if err != nil {
+					return c.Status(400).JSON(fiber.Map{"error": "Failed to read request body"})
+				}
+				if int64(len(body)) > limit {
+					return c.Status(413).JSON(fiber.Map{"error": "Request body too large"})
+				}
+				c.Request().SetBody(body)
+			}
+		}
+		return c.Next()
+	}
+}
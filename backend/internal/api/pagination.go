@@ -0,0 +1,55 @@
+package api
+
+import (
+	"backend/internal/fabric"
+	"backend/internal/models"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ParseAssetQuery reads ?limit=&bookmark=&owner=&status=&view= from the request
+func ParseAssetQuery(c *fiber.Ctx) (fabric.AssetQuery, error) {
+	limit := c.QueryInt("limit", DefaultPageSize)
+	if limit <= 0 || limit > MaxPageSize {
+		return fabric.AssetQuery{}, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	return fabric.AssetQuery{
+		OwnerID:  c.Query("owner"),
+		Status:   strings.ToUpper(c.Query("status")),
+		View:     strings.ToUpper(c.Query("view")),
+		PageSize: int32(limit),
+		Bookmark: c.Query("bookmark"),
+	}, nil
+}
+
+// FlattenPage converts a page of ledger values into frontend-friendly assets
+func FlattenPage(page *models.PaginatedQueryResult) []models.Asset {
+	assets := []models.Asset{}
+	for _, val := range page.Records {
+		assets = append(assets, val.ToAsset())
+	}
+	return assets
+}
+
+// VisibleAssets keeps the assets a non-admin may list, matching the projection's listing rule:
+// PUBLIC ones and those fullID owns or is offered, never DELETED ones
+func VisibleAssets(assets []models.Asset, fullID string) []models.Asset {
+	visible := []models.Asset{}
+	for _, asset := range assets {
+		if asset.Status == "DELETED" {
+			continue
+		}
+		if strings.EqualFold(asset.View, "PUBLIC") || asset.OwnerID == fullID || asset.ProposedOwnerID == fullID {
+			visible = append(visible, asset)
+		}
+	}
+	return visible
+}
@@ -4,76 +4,380 @@ import (
 	"backend/internal/models"
 	"backend/internal/storage"
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime/multipart"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/minio/minio-go/v7"
 	"strings"
 )
 
 type StorageHandler struct {
 	MinIO *storage.MinIOStorage
 	Ipfs  *shell.Shell
+	// MaxUploadSize bounds the request body of an upload, in bytes (0 means unlimited)
+	MaxUploadSize int64
 }
 
+// ErrUploadTooLarge is returned while streaming an upload larger than MaxUploadSize
+var ErrUploadTooLarge = errors.New("upload exceeds the maximum size")
+
+// Upload streams the multipart "file" field to storage and returns its attachment metadata
 func (h *StorageHandler) Upload(c *fiber.Ctx) error {
-	file, err := c.FormFile("file")
+	attachments, err := h.streamFiles(c, "file", 1)
 	if err != nil {
+		return uploadError(c, err)
+	}
+	if len(attachments) == 0 {
 		return c.Status(400).JSON(fiber.Map{"error": "No file uploaded"})
 	}
+	return c.JSON(attachments[0])
+}
 
-	src, err := file.Open()
+// UploadMany stores every file of the multipart "files" field and returns their attachment
+// metadata in the same order, ready to be added to an asset
+func (h *StorageHandler) UploadMany(c *fiber.Ctx) error {
+	attachments, err := h.streamFiles(c, "files", MaxUploadFiles)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to open file"})
+		return uploadError(c, err)
+	}
+	if len(attachments) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No files uploaded"})
 	}
-	defer src.Close()
+	return c.JSON(attachments)
+}
 
-	// Read content to calculate hash and upload to multiple sinks
-	content, err := io.ReadAll(src)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to read file"})
+// MaxUploadFiles matches the chaincode's limit on attachments per asset
+const MaxUploadFiles = 20
+
+// uploadRequestError is a problem with the request itself rather than with storage
+type uploadRequestError struct{ message string }
+
+func (e uploadRequestError) Error() string { return e.message }
+
+func uploadError(c *fiber.Ctx, err error) error {
+	var requestErr uploadRequestError
+	switch {
+	case errors.Is(err, ErrUploadTooLarge):
+		return c.Status(413).JSON(fiber.Map{"error": err.Error()})
+	case errors.As(err, &requestErr):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+}
 
-	// 1. Calculate Hash
-	hash := sha256.Sum256(content)
-	fileHash := hex.EncodeToString(hash[:])
+// streamFiles reads the multipart body part by part and stores the files of field as they
+// arrive, so no file is ever held in memory or spooled to disk. Other fields are skipped.
+func (h *StorageHandler) streamFiles(c *fiber.Ctx, field string, maxFiles int) ([]models.AssetAttachment, error) {
+	boundary := string(c.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return nil, uploadRequestError{"Expected a multipart/form-data upload"}
+	}
 
-	// 2. Upload to IPFS
-	var ipfsCID string
-	if h.Ipfs != nil {
-		ipfsCID, err = h.Ipfs.Add(bytes.NewReader(content))
+	if length := c.Request().Header.ContentLength(); h.MaxUploadSize > 0 && int64(length) > h.MaxUploadSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	if h.MaxUploadSize > 0 {
+		body = &limitedReader{r: body, remaining: h.MaxUploadSize}
+	}
+
+	reader := multipart.NewReader(body, boundary)
+	attachments := []models.AssetAttachment{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return attachments, nil
+		}
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("IPFS upload failed: %v", err)})
+			if errors.Is(err, ErrUploadTooLarge) {
+				return nil, err
+			}
+			return nil, uploadRequestError{fmt.Sprintf("Malformed multipart body: %v", err)}
+		}
+		if part.FormName() != field || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		if len(attachments) == maxFiles {
+			part.Close()
+			return nil, uploadRequestError{fmt.Sprintf("At most %d files can be uploaded at once", maxFiles)}
 		}
+
+		attachment, err := h.store(c.Context(), part.FileName(), part.Header.Get("Content-Type"), part)
+		part.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", part.FileName(), err)
+		}
+		attachments = append(attachments, attachment)
 	}
+}
+
+// store streams one file to MinIO and IPFS at the same time, hashing it on the way
+func (h *StorageHandler) store(ctx context.Context, fileName string, contentType string, src io.Reader) (models.AssetAttachment, error) {
+	objectName := newObjectName(fileName)
 
-	// 3. Upload to MinIO
-	var storagePath string
-	// Sanitize filename: replace spaces with underscores to avoid URL encoding headaches
-	safeFilename := strings.ReplaceAll(file.Filename, " ", "_")
-	objectName := fmt.Sprintf("%d_%s", time.Now().Unix(), safeFilename)
+	var ipfsCID, storagePath string
+	var sinks []storage.Sink
+	if h.Ipfs != nil {
+		sinks = append(sinks, func(r io.Reader) error {
+			cid, err := h.Ipfs.Add(r)
+			if err != nil {
+				return fmt.Errorf("IPFS upload failed: %v", err)
+			}
+			ipfsCID = cid
+			return nil
+		})
+	}
 	if h.MinIO != nil {
-		storagePath, err = h.MinIO.Upload(objectName, bytes.NewReader(content), file.Size, file.Header.Get("Content-Type"))
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("MinIO upload failed: %v", err)})
+		sinks = append(sinks, func(r io.Reader) error {
+			path, err := h.MinIO.UploadStream(ctx, objectName, r, contentType)
+			if err != nil {
+				return fmt.Errorf("MinIO upload failed: %v", err)
+			}
+			storagePath = path
+			return nil
+		})
+	}
+
+	fileHash, fileSize, err := storage.Tee(src, sinks...)
+	if err != nil {
+		// Don't leave a half-registered file behind when the other sink failed
+		if storagePath != "" {
+			if removeErr := h.MinIO.Remove(context.Background(), storagePath); removeErr != nil {
+				log.Printf("Storage Warning: failed to remove %s after a failed upload: %v", storagePath, removeErr)
+			}
 		}
+		return models.AssetAttachment{}, err
 	}
 
-	// 4. Return the Attachment Metadata
-	attachment := models.AssetAttachment{
-		FileName:    file.Filename,
-		FileSize:    file.Size,
+	return models.AssetAttachment{
+		FileName:    fileName,
+		FileSize:    fileSize,
 		FileHash:    fileHash,
 		IpfsCID:     ipfsCID,
 		StoragePath: storagePath,
 		StorageType: "minio",
+	}, nil
+}
+
+// newObjectName derives a unique MinIO object name from an uploaded file's name
+func newObjectName(fileName string) string {
+	// Sanitize filename: replace spaces with underscores and drop path separators so the name
+	// works as a single URL segment
+	safeFilename := strings.NewReplacer(" ", "_", "/", "_", "\\", "_").Replace(fileName)
+	return fmt.Sprintf("%d_%s", time.Now().UnixNano(), safeFilename)
+}
+
+// PresignExpiry is how long a direct upload URL or policy stays valid
+const PresignExpiry = 15 * time.Minute
+
+// uploaderMetadata is the object metadata key recording who may complete a direct upload
+const uploaderMetadata = "Uploader"
+
+// Presign issues a presigned PUT URL (default) or POST policy for uploading one file straight to
+// MinIO, bypassing the backend. Size and content type are fixed by the signature and the object
+// is tagged with the caller, who then calls Complete to get a verified attachment.
+func (h *StorageHandler) Presign(c *fiber.Ctx) error {
+	if h.MinIO == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Storage service unavailable"})
 	}
 
-	return c.JSON(attachment)
+	type PresignReq struct {
+		FileName    string `json:"file_name"`
+		FileSize    int64  `json:"file_size"`
+		ContentType string `json:"content_type"`
+		Method      string `json:"method"` // PUT or POST
+	}
+	req := new(PresignReq)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.FileName == "" || req.FileSize <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "file_name and a positive file_size are required"})
+	}
+	if h.MaxUploadSize > 0 && req.FileSize > h.MaxUploadSize {
+		return c.Status(413).JSON(fiber.Map{"error": ErrUploadTooLarge.Error()})
+	}
+	if req.ContentType == "" {
+		req.ContentType = "application/octet-stream"
+	}
+
+	objectName := newObjectName(req.FileName)
+	metadata := map[string]string{uploaderMetadata: callerID(c)}
+	expiresAt := time.Now().Add(PresignExpiry)
+
+	switch strings.ToUpper(req.Method) {
+	case "", "PUT":
+		url, headers, err := h.MinIO.PresignedPut(c.Context(), objectName, req.ContentType, req.FileSize, metadata, PresignExpiry)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		flat := make(map[string]string, len(headers))
+		for key := range headers {
+			flat[key] = headers.Get(key)
+		}
+		return c.JSON(fiber.Map{
+			"object_name": objectName,
+			"method":      "PUT",
+			"url":         url,
+			"headers":     flat,
+			"expires_at":  expiresAt,
+		})
+	case "POST":
+		url, fields, err := h.MinIO.PresignedPost(c.Context(), objectName, req.ContentType, req.FileSize, metadata, PresignExpiry)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{
+			"object_name": objectName,
+			"method":      "POST",
+			"url":         url,
+			"fields":      fields,
+			"expires_at":  expiresAt,
+		})
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "method must be PUT or POST"})
+	}
+}
+
+// Complete verifies a direct upload: it streams the object back from MinIO, hashes it and pins it
+// to IPFS, then returns the attachment metadata. When the client sends the file_hash it computed,
+// a different hash rejects the upload and deletes the object.
+func (h *StorageHandler) Complete(c *fiber.Ctx) error {
+	if h.MinIO == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Storage service unavailable"})
+	}
+
+	type CompleteReq struct {
+		ObjectName string `json:"object_name"`
+		FileName   string `json:"file_name"`
+		FileHash   string `json:"file_hash"` // Optional SHA-256 computed by the client
+	}
+	req := new(CompleteReq)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.ObjectName == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "object_name is required"})
+	}
+
+	info, err := h.MinIO.Stat(c.Context(), req.ObjectName)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return c.Status(404).JSON(fiber.Map{"error": "Upload not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("Failed to inspect upload: %v", err)})
+	}
+	// Only objects uploaded through a presign issued to the caller can be completed
+	if info.UserMetadata[uploaderMetadata] != callerID(c) {
+		return c.Status(403).JSON(fiber.Map{"error": "Upload belongs to another user"})
+	}
+	if h.MaxUploadSize > 0 && info.Size > h.MaxUploadSize {
+		h.discard(req.ObjectName, "")
+		return c.Status(413).JSON(fiber.Map{"error": ErrUploadTooLarge.Error()})
+	}
+
+	fileHash, fileSize, ipfsCID, err := h.verify(c.Context(), req.ObjectName)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.FileHash != "" && !strings.EqualFold(req.FileHash, fileHash) {
+		h.discard(req.ObjectName, ipfsCID)
+		return c.Status(422).JSON(fiber.Map{
+			"error":         "Uploaded content does not match file_hash",
+			"expected_hash": strings.ToLower(req.FileHash),
+			"actual_hash":   fileHash,
+		})
+	}
+
+	fileName := req.FileName
+	if fileName == "" {
+		fileName = req.ObjectName
+	}
+	return c.JSON(models.AssetAttachment{
+		FileName:    fileName,
+		FileSize:    fileSize,
+		FileHash:    fileHash,
+		IpfsCID:     ipfsCID,
+		StoragePath: req.ObjectName,
+		StorageType: "minio",
+	})
+}
+
+// verify streams an object back from MinIO, hashing it and pinning it to IPFS on the way
+func (h *StorageHandler) verify(ctx context.Context, objectName string) (fileHash string, fileSize int64, ipfsCID string, err error) {
+	object, err := h.MinIO.Open(ctx, objectName)
+	if err != nil {
+		return "", 0, "", err
+	}
+	defer object.Close()
+
+	var sinks []storage.Sink
+	if h.Ipfs != nil {
+		sinks = append(sinks, func(r io.Reader) error {
+			cid, err := h.Ipfs.Add(r)
+			if err != nil {
+				return fmt.Errorf("IPFS upload failed: %v", err)
+			}
+			ipfsCID = cid
+			return nil
+		})
+	}
+	fileHash, fileSize, err = storage.Tee(object, sinks...)
+	if err != nil {
+		return "", 0, "", err
+	}
+	return fileHash, fileSize, ipfsCID, nil
+}
+
+// discard removes a rejected direct upload and unpins its IPFS copy, if any
+func (h *StorageHandler) discard(objectName string, ipfsCID string) {
+	if err := h.MinIO.Remove(context.Background(), objectName); err != nil {
+		log.Printf("Storage Warning: failed to remove rejected upload %s: %v", objectName, err)
+	}
+	if ipfsCID != "" {
+		if err := h.Ipfs.Unpin(ipfsCID); err != nil {
+			log.Printf("Storage Warning: failed to unpin rejected upload %s: %v", ipfsCID, err)
+		}
+	}
+}
+
+func callerID(c *fiber.Ctx) string {
+	return fmt.Sprintf("%s::%s", c.Locals("org").(string), c.Locals("user").(string))
+}
+
+// limitedReader fails with ErrUploadTooLarge once more than remaining bytes have been read
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrUploadTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
 }
 
 func (h *StorageHandler) GetURL(c *fiber.Ctx) error {
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"backend/internal/fabric"
+	"backend/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"gorm.io/gorm"
+)
+
+// TxIDLocal is the fiber local under which SubmitAsset leaves the submitted transaction ID
+const TxIDLocal = "tx_id"
+
+// Submission is an asset mutation to submit on the caller's contract
+type Submission struct {
+	Function string
+	// AssetID is the asset the transaction changes; empty when creating an asset whose ID the
+	// chaincode generates and returns as the transaction result
+	AssetID string
+	Options []client.ProposalOption
+	// OnCommit is the route's follow-up work (DB updates, notifications) once the transaction
+	// committed, given the asset ID. For async requests it runs after the handler returned, so it
+	// must not use the fiber.Ctx or strings borrowed from it (c.Params values must be copied).
+	OnCommit func(assetID string)
+}
+
+// SubmitAsset submits s and reports whether the response has already been written, along with
+// the asset ID. By default it blocks until commit, runs OnCommit and leaves the success response
+// to the caller. With ?async=true the request is answered 202 with the transaction and asset IDs
+// as soon as the orderer accepted it; GET /tx/:txid then reports the commit status. A blocking
+// submit whose commit status doesn't arrive is tracked the same way and answered with its error.
+func SubmitAsset(c *fiber.Ctx, transactions *fabric.TransactionTracker, lease *fabric.GatewayLease, s Submission) (bool, string, error) {
+	record := &models.Transaction{
+		Function:  s.Function,
+		AssetID:   s.AssetID,
+		Submitter: fmt.Sprintf("%s::%s", c.Locals("org").(string), c.Locals("user").(string)),
+	}
+	var onCommit func()
+	if s.OnCommit != nil {
+		// Submit and SubmitAsync have set record.AssetID by the time the commit is awaited
+		onCommit = func() { s.OnCommit(record.AssetID) }
+	}
+
+	if !c.QueryBool("async") {
+		result, err := transactions.Submit(lease, record, onCommit, s.Options...)
+		if err != nil {
+			if record.Status == fabric.TxSubmitted {
+				// The transaction may still commit; Idempotent binds the key to it
+				c.Locals(TxIDLocal, record.TxID)
+			}
+			return true, "", ContractError(c, err)
+		}
+		c.Locals(TxIDLocal, record.TxID)
+		assetID := s.AssetID
+		if assetID == "" {
+			assetID = string(result)
+		}
+		if onCommit != nil {
+			onCommit()
+		}
+		return false, assetID, nil
+	}
+
+	txID, err := transactions.SubmitAsync(lease, record, onCommit, s.Options...)
+	if err != nil {
+		return true, "", ContractError(c, err)
+	}
+	c.Locals(TxIDLocal, txID)
+	return true, record.AssetID, c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"tx_id":      txID,
+		"asset_id":   record.AssetID,
+		"status":     fabric.TxSubmitted,
+		"status_url": "/tx/" + txID,
+	})
+}
+
+// TransactionHandler reports the status of asynchronously submitted transactions
+type TransactionHandler struct {
+	Transactions *fabric.TransactionTracker
+}
+
+// GetTransaction returns a tracked transaction to its submitter or an admin
+func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
+	record, err := h.Transactions.Get(c.Params("txid"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Transaction not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+	}
+
+	// Other users' transactions are reported as missing rather than forbidden
+	fullID := fmt.Sprintf("%s::%s", c.Locals("org").(string), c.Locals("user").(string))
+	if c.Locals("role").(string) != "admin" && record.Submitter != fullID {
+		return c.Status(404).JSON(fiber.Map{"error": "Transaction not found"})
+	}
+	return c.JSON(record)
+}
@@ -0,0 +1,493 @@
+package api
+
+import (
+	"backend/internal/models"
+	"backend/internal/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// TusVersion is the tus protocol version served under /api/storage/tus
+const TusVersion = "1.0.0"
+
+// TusPartSize is the size of every stored part but the last. Chunks are regrouped into parts of
+// this size whatever size the client sends, so it is also the most a PATCH holds in memory.
+const TusPartSize = storage.MinPartSize
+
+// tusLease is how long a PATCH may hold an upload before another request can take it over
+const tusLease = 10 * time.Minute
+
+// TusHandler serves resumable uploads following the tus 1.0 core protocol with the creation and
+// termination extensions. Chunks go to a MinIO multipart upload and the offset is kept in
+// Postgres, so an interrupted upload resumes from the last stored byte on any backend instance.
+// A completed upload is verified like a direct upload and yields the same AssetAttachment.
+type TusHandler struct {
+	Storage *StorageHandler
+	DB      *gorm.DB
+}
+
+// Options answers tus protocol discovery
+func (h *TusHandler) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", TusVersion)
+	c.Set("Tus-Version", TusVersion)
+	c.Set("Tus-Extension", "creation,termination")
+	if h.Storage.MaxUploadSize > 0 {
+		c.Set("Tus-Max-Size", strconv.FormatInt(h.Storage.MaxUploadSize, 10))
+	}
+	return c.SendStatus(204)
+}
+
+// Create starts an upload of Upload-Length bytes. Upload-Metadata may carry the filename and
+// filetype (base64 values, as sent by tus clients).
+func (h *TusHandler) Create(c *fiber.Ctx) error {
+	if err := h.checkVersion(c); err != nil {
+		return err
+	}
+	if h.Storage.MinIO == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Storage service unavailable"})
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Upload-Length must be a positive integer"})
+	}
+	if h.Storage.MaxUploadSize > 0 && length > h.Storage.MaxUploadSize {
+		return c.Status(413).JSON(fiber.Map{"error": ErrUploadTooLarge.Error()})
+	}
+
+	metadata, err := parseTusMetadata(c.Get("Upload-Metadata"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	fileName := metadata["filename"]
+	if fileName == "" {
+		fileName = "upload"
+	}
+	contentType := metadata["filetype"]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	id, err := newTusID()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	objectName := newObjectName(fileName)
+	multipartID, err := h.Storage.MinIO.NewMultipartUpload(c.Context(), objectName, contentType)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	upload := models.TusUpload{
+		ID:           id,
+		Owner:        callerID(c),
+		ObjectName:   objectName,
+		MultipartID:  multipartID,
+		FileName:     fileName,
+		ContentType:  contentType,
+		UploadLength: length,
+	}
+	if err := h.DB.Create(&upload).Error; err != nil {
+		if abortErr := h.Storage.MinIO.AbortMultipartUpload(c.Context(), objectName, multipartID); abortErr != nil {
+			log.Printf("Storage Warning: failed to abort multipart upload of %s: %v", objectName, abortErr)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+	}
+
+	c.Location(c.BaseURL() + "/api/storage/tus/" + id)
+	return c.SendStatus(201)
+}
+
+// Head reports how many bytes of an upload are stored
+func (h *TusHandler) Head(c *fiber.Ctx) error {
+	if err := h.checkVersion(c); err != nil {
+		return err
+	}
+	upload, err := h.load(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(upload.UploadLength, 10))
+	c.Set("Cache-Control", "no-store")
+	return c.SendStatus(200)
+}
+
+// Patch appends a chunk at Upload-Offset. Bytes received before the connection drops are kept,
+// so the client resumes from the offset reported by Head. The chunk completing the upload also
+// assembles the object, hashes it and pins it to IPFS.
+func (h *TusHandler) Patch(c *fiber.Ctx) error {
+	if err := h.checkVersion(c); err != nil {
+		return err
+	}
+	if c.Get("Content-Type") != "application/offset+octet-stream" {
+		return c.Status(415).JSON(fiber.Map{"error": "Content-Type must be application/offset+octet-stream"})
+	}
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Upload-Offset must be a non-negative integer"})
+	}
+
+	if _, err := h.load(c); err != nil {
+		return err
+	}
+	if !h.lock(c.Params("id")) {
+		return c.Status(423).JSON(fiber.Map{"error": "Upload is being written by another request"})
+	}
+	defer h.unlock(c.Params("id"))
+
+	// Reload under the lease, the offset may have moved since
+	upload, err := h.load(c)
+	if err != nil {
+		return err
+	}
+	if offset != upload.UploadOffset {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("Upload-Offset %d does not match the stored offset %d", offset, upload.UploadOffset)})
+	}
+	if upload.Completed {
+		c.Set("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+		return c.SendStatus(204)
+	}
+
+	remaining := upload.UploadLength - upload.UploadOffset
+	if int64(c.Request().Header.ContentLength()) > remaining {
+		return c.Status(413).JSON(fiber.Map{"error": "Chunk exceeds Upload-Length"})
+	}
+
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	storedOffset, writeErr := h.write(c, upload, io.LimitReader(body, remaining))
+
+	c.Set("Upload-Offset", strconv.FormatInt(storedOffset, 10))
+	if writeErr != nil {
+		log.Printf("Storage Warning: tus upload %s stopped at offset %d: %v", upload.ID, storedOffset, writeErr)
+		if storedOffset == upload.UploadOffset {
+			return c.Status(500).JSON(fiber.Map{"error": writeErr.Error()})
+		}
+		return c.SendStatus(204)
+	}
+
+	if storedOffset == upload.UploadLength {
+		// A chunked body may still run past Upload-Length; the upload is then left unfinished
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			return c.Status(413).JSON(fiber.Map{"error": "Chunk exceeds Upload-Length"})
+		}
+		if err := h.finish(c, upload); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	return c.SendStatus(204)
+}
+
+// tusPartStore is the part of MinIOStorage that writes tus chunks
+type tusPartStore interface {
+	UploadPart(ctx context.Context, objectName, uploadID string, number int, data []byte) (string, error)
+	Upload(objectName string, reader io.Reader, size int64, contentType string) (string, error)
+	Open(ctx context.Context, objectName string) (io.ReadCloser, error)
+}
+
+// write stores a chunk (see writeParts) and records the new offset. It returns the stored offset
+// and why it stopped early.
+func (h *TusHandler) write(c *fiber.Ctx, upload *models.TusUpload, chunk io.Reader) (int64, error) {
+	stored, etags, stopErr := writeParts(c.Context(), h.Storage.MinIO, upload, chunk)
+
+	// The offset only advances if no other request moved it meanwhile. Stale uploads are
+	// expired by updated_at, so every stored chunk counts as activity.
+	result := h.DB.Model(&models.TusUpload{}).
+		Where("id = ? AND upload_offset = ?", upload.ID, upload.UploadOffset).
+		Select("UploadOffset", "PartETags", "UpdatedAt").
+		Updates(&models.TusUpload{UploadOffset: stored, PartETags: etags, UpdatedAt: time.Now()})
+	if result.Error != nil {
+		return upload.UploadOffset, fmt.Errorf("failed to record offset: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return upload.UploadOffset, errors.New("upload offset changed concurrently")
+	}
+	upload.UploadOffset = stored
+	upload.PartETags = etags
+	return stored, stopErr
+}
+
+// writeParts stores a chunk as full parts, keeping the remainder (at most one part) as the tail
+// object. It returns the offset and part ETags that are now stored, and why it stopped early.
+func writeParts(ctx context.Context, store tusPartStore, upload *models.TusUpload, chunk io.Reader) (int64, []string, error) {
+	tailName := upload.ObjectName + ".tail"
+	etags := append([]string(nil), upload.PartETags...)
+
+	part := make([]byte, 0, TusPartSize)
+	if tailSize := upload.UploadOffset - int64(len(etags))*TusPartSize; tailSize > 0 {
+		tail, err := readAll(ctx, store, tailName)
+		if err != nil || int64(len(tail)) != tailSize {
+			return upload.UploadOffset, etags, fmt.Errorf("tail of upload %s is unreadable: %v", upload.ID, err)
+		}
+		part = append(part, tail...)
+	}
+
+	var stopErr error
+	for done := false; ; {
+		if len(part) == TusPartSize {
+			etag, err := store.UploadPart(ctx, upload.ObjectName, upload.MultipartID, len(etags)+1, part)
+			if err != nil {
+				stopErr = err
+				break
+			}
+			etags = append(etags, etag)
+			part = part[:0]
+		}
+		if done {
+			break
+		}
+
+		n, err := io.ReadFull(chunk, part[len(part):TusPartSize])
+		part = part[:len(part)+n]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			done = true
+		} else if err != nil {
+			stopErr = err
+			done = true
+		}
+	}
+
+	stored := int64(len(etags))*TusPartSize + int64(len(part))
+	switch {
+	case len(part) == 0:
+	case stored == upload.UploadLength && stopErr == nil:
+		// The last part may be smaller than TusPartSize
+		etag, err := store.UploadPart(ctx, upload.ObjectName, upload.MultipartID, len(etags)+1, part)
+		if err == nil {
+			etags = append(etags, etag)
+			break
+		}
+		stopErr = err
+		fallthrough
+	default:
+		if _, err := store.Upload(tailName, bytes.NewReader(part), int64(len(part)), "application/octet-stream"); err != nil {
+			if stopErr == nil {
+				stopErr = err
+			}
+			stored = int64(len(etags)) * TusPartSize
+		}
+	}
+	return stored, etags, stopErr
+}
+
+// finish assembles a fully received upload and verifies it
+func (h *TusHandler) finish(c *fiber.Ctx, upload *models.TusUpload) error {
+	minio := h.Storage.MinIO
+	if err := minio.CompleteMultipartUpload(c.Context(), upload.ObjectName, upload.MultipartID, upload.PartETags); err != nil {
+		// A retry after a failed verification finds the object already assembled
+		if _, statErr := minio.Stat(c.Context(), upload.ObjectName); statErr != nil {
+			return err
+		}
+	}
+	h.removeTail(c.Context(), upload)
+
+	fileHash, fileSize, ipfsCID, err := h.Storage.verify(c.Context(), upload.ObjectName)
+	if err != nil {
+		return err
+	}
+	if fileSize != upload.UploadLength {
+		return fmt.Errorf("assembled object has %d bytes, expected %d", fileSize, upload.UploadLength)
+	}
+
+	upload.FileHash = fileHash
+	upload.IpfsCID = ipfsCID
+	upload.Completed = true
+	return h.DB.Model(upload).Select("FileHash", "IpfsCID", "Completed").Updates(upload).Error
+}
+
+// Attachment returns the AssetAttachment of a completed upload, as StorageHandler.Upload would
+func (h *TusHandler) Attachment(c *fiber.Ctx) error {
+	upload, err := h.load(c)
+	if err != nil {
+		return err
+	}
+	if !upload.Completed {
+		return c.Status(409).JSON(fiber.Map{"error": "Upload is not complete", "offset": upload.UploadOffset, "length": upload.UploadLength})
+	}
+
+	return c.JSON(models.AssetAttachment{
+		FileName:    upload.FileName,
+		FileSize:    upload.UploadLength,
+		FileHash:    upload.FileHash,
+		IpfsCID:     upload.IpfsCID,
+		StoragePath: upload.ObjectName,
+		StorageType: "minio",
+	})
+}
+
+// Terminate abandons an upload and frees its parts (see discard)
+func (h *TusHandler) Terminate(c *fiber.Ctx) error {
+	if err := h.checkVersion(c); err != nil {
+		return err
+	}
+	upload, err := h.load(c)
+	if err != nil {
+		return err
+	}
+	if !h.lock(upload.ID) {
+		return c.Status(423).JSON(fiber.Map{"error": "Upload is being written by another request"})
+	}
+	defer h.unlock(upload.ID)
+
+	if err := h.discard(c.Context(), upload); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
+
+// ExpireStale discards the uploads that stored no chunk since cutoff, as if their owners had
+// terminated them. Uploads being written are skipped and picked up by a later sweep.
+func (h *TusHandler) ExpireStale(ctx context.Context, cutoff time.Time) {
+	var uploads []models.TusUpload
+	if err := h.DB.Where("updated_at < ?", cutoff).Find(&uploads).Error; err != nil {
+		log.Printf("Storage Warning: failed to list stale tus uploads: %v", err)
+		return
+	}
+
+	expired := 0
+	for i := range uploads {
+		upload := &uploads[i]
+		if !h.lock(upload.ID) {
+			continue
+		}
+		if err := h.discard(ctx, upload); err != nil {
+			log.Printf("Storage Warning: failed to expire tus upload %s: %v", upload.ID, err)
+			h.unlock(upload.ID)
+			continue
+		}
+		expired++
+	}
+	if expired > 0 {
+		log.Printf("Expired %d tus uploads idle since %s", expired, cutoff.Format(time.RFC3339))
+	}
+}
+
+// RunExpiry expires uploads idle for maxAge every interval until ctx is cancelled
+func (h *TusHandler) RunExpiry(ctx context.Context, interval, maxAge time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.ExpireStale(ctx, time.Now().Add(-maxAge))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discard frees the parts of an unfinished upload and deletes its row. A completed upload only
+// loses its row; the object stays, since it may already be attached to an asset.
+func (h *TusHandler) discard(ctx context.Context, upload *models.TusUpload) error {
+	if !upload.Completed {
+		if err := h.Storage.MinIO.AbortMultipartUpload(ctx, upload.ObjectName, upload.MultipartID); err != nil {
+			return err
+		}
+		h.removeTail(ctx, upload)
+	}
+	if err := h.DB.Delete(&models.TusUpload{}, "id = ?", upload.ID).Error; err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+	return nil
+}
+
+// removeTail deletes the bytes kept past the last full part. A tail consumed by a later part is
+// not removed by write, so this runs whatever the offset.
+func (h *TusHandler) removeTail(ctx context.Context, upload *models.TusUpload) {
+	if err := h.Storage.MinIO.Remove(ctx, upload.ObjectName+".tail"); err != nil {
+		log.Printf("Storage Warning: failed to remove tail of tus upload %s: %v", upload.ID, err)
+	}
+}
+
+// checkVersion rejects requests for another tus version with 412
+func (h *TusHandler) checkVersion(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", TusVersion)
+	if c.Get("Tus-Resumable") != TusVersion {
+		c.Set("Tus-Version", TusVersion)
+		return c.Status(412).JSON(fiber.Map{"error": "Unsupported Tus-Resumable version"})
+	}
+	return nil
+}
+
+// load fetches the caller's upload named by the :id parameter; uploads of other users are
+// reported as missing
+func (h *TusHandler) load(c *fiber.Ctx) (*models.TusUpload, error) {
+	var upload models.TusUpload
+	err := h.DB.Where("id = ? AND owner = ?", c.Params("id"), callerID(c)).First(&upload).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Upload not found"})
+	}
+	if err != nil {
+		return nil, c.Status(500).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+	}
+	return &upload, nil
+}
+
+// lock takes the upload's write lease, so two PATCH requests never write parts at once
+func (h *TusHandler) lock(id string) bool {
+	now := time.Now()
+	result := h.DB.Model(&models.TusUpload{}).
+		Where("id = ? AND locked_until < ?", id, now).
+		UpdateColumn("locked_until", now.Add(tusLease))
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func (h *TusHandler) unlock(id string) {
+	h.DB.Model(&models.TusUpload{}).Where("id = ?", id).UpdateColumn("locked_until", time.Time{})
+}
+
+// parseTusMetadata decodes Upload-Metadata: comma-separated "key base64value" pairs
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 0:
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid Upload-Metadata value for %s", fields[0])
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, errors.New("malformed Upload-Metadata")
+		}
+	}
+	return metadata, nil
+}
+
+func newTusID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate upload ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func readAll(ctx context.Context, store tusPartStore, objectName string) ([]byte, error) {
+	object, err := store.Open(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	return io.ReadAll(object)
+}
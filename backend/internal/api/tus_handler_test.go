@@ -0,0 +1,172 @@
+package api
+
+import (
+	"backend/internal/models"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// fakePartStore keeps parts and objects in memory
+type fakePartStore struct {
+	parts      map[int][]byte
+	objects    map[string][]byte
+	failPart   int // part number whose upload fails
+	failUpload bool
+}
+
+func newFakePartStore() *fakePartStore {
+	return &fakePartStore{parts: map[int][]byte{}, objects: map[string][]byte{}}
+}
+
+func (s *fakePartStore) UploadPart(ctx context.Context, objectName, uploadID string, number int, data []byte) (string, error) {
+	if number == s.failPart {
+		return "", errors.New("part upload failed")
+	}
+	s.parts[number] = append([]byte(nil), data...)
+	return fmt.Sprintf("etag-%d", number), nil
+}
+
+func (s *fakePartStore) Upload(objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	if s.failUpload {
+		return "", errors.New("object upload failed")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) != size {
+		return "", fmt.Errorf("read %d bytes, size %d", len(data), size)
+	}
+	s.objects[objectName] = data
+	return objectName, nil
+}
+
+func (s *fakePartStore) Open(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	data, ok := s.objects[objectName]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// brokenReader returns its data and then fails, like a dropped connection
+type brokenReader struct{ data io.Reader }
+
+func (r brokenReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+// testBytes returns n bytes whose values depend on their position
+func testBytes(from, n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte((from + i) % 251)
+	}
+	return data
+}
+
+func testUpload(length, offset int64, etags ...string) *models.TusUpload {
+	return &models.TusUpload{ID: "u1", ObjectName: "obj", MultipartID: "mp", UploadLength: length, UploadOffset: offset, PartETags: etags}
+}
+
+func TestWritePartsKeepsSmallChunkAsTail(t *testing.T) {
+	store := newFakePartStore()
+	stored, etags, err := writeParts(context.Background(), store, testUpload(3*TusPartSize, 0), bytes.NewReader(testBytes(0, 1000)))
+	if err != nil || stored != 1000 || len(etags) != 0 {
+		t.Fatalf("writeParts = %d, %v, %v", stored, etags, err)
+	}
+	if len(store.parts) != 0 || !bytes.Equal(store.objects["obj.tail"], testBytes(0, 1000)) {
+		t.Fatalf("unexpected store: %d parts, tail of %d bytes", len(store.parts), len(store.objects["obj.tail"]))
+	}
+}
+
+func TestWritePartsCompletesPartFromTail(t *testing.T) {
+	store := newFakePartStore()
+	store.objects["obj.tail"] = testBytes(TusPartSize, 1000)
+	upload := testUpload(4*TusPartSize, TusPartSize+1000, "etag-1")
+
+	// Completes part 2, fills part 3 and leaves 500 bytes
+	chunk := testBytes(TusPartSize+1000, 2*TusPartSize-1000+500)
+	stored, etags, err := writeParts(context.Background(), store, upload, bytes.NewReader(chunk))
+	if err != nil {
+		t.Fatalf("writeParts: %v", err)
+	}
+	if want := int64(3*TusPartSize + 500); stored != want {
+		t.Fatalf("stored = %d, want %d", stored, want)
+	}
+	if len(etags) != 3 || etags[0] != "etag-1" || etags[2] != "etag-3" {
+		t.Fatalf("etags = %v", etags)
+	}
+	if !bytes.Equal(store.parts[2], testBytes(TusPartSize, TusPartSize)) || !bytes.Equal(store.parts[3], testBytes(2*TusPartSize, TusPartSize)) {
+		t.Fatalf("parts do not hold the tail followed by the chunk")
+	}
+	if !bytes.Equal(store.objects["obj.tail"], testBytes(3*TusPartSize, 500)) {
+		t.Fatalf("tail holds %d bytes, want the last 500", len(store.objects["obj.tail"]))
+	}
+	if len(upload.PartETags) != 1 {
+		t.Fatalf("writeParts changed the upload's ETags: %v", upload.PartETags)
+	}
+}
+
+func TestWritePartsUploadsShortLastPart(t *testing.T) {
+	store := newFakePartStore()
+	store.objects["obj.tail"] = testBytes(TusPartSize, 100)
+	upload := testUpload(TusPartSize+300, TusPartSize+100, "etag-1")
+
+	stored, etags, err := writeParts(context.Background(), store, upload, bytes.NewReader(testBytes(TusPartSize+100, 200)))
+	if err != nil || stored != upload.UploadLength || len(etags) != 2 {
+		t.Fatalf("writeParts = %d, %v, %v", stored, etags, err)
+	}
+	if !bytes.Equal(store.parts[2], testBytes(TusPartSize, 300)) {
+		t.Fatalf("last part holds %d bytes, want 300", len(store.parts[2]))
+	}
+	if !bytes.Equal(store.objects["obj.tail"], testBytes(TusPartSize, 100)) {
+		t.Fatalf("the last part was also written as the tail")
+	}
+}
+
+func TestWritePartsRejectsMismatchingTail(t *testing.T) {
+	store := newFakePartStore()
+	store.objects["obj.tail"] = testBytes(0, 99)
+
+	stored, _, err := writeParts(context.Background(), store, testUpload(TusPartSize, 100), bytes.NewReader(testBytes(100, 10)))
+	if err == nil || stored != 100 {
+		t.Fatalf("writeParts = %d, %v; want the offset kept and an error", stored, err)
+	}
+}
+
+func TestWritePartsKeepsBytesBeforeAFailure(t *testing.T) {
+	// A failed part upload keeps the full part as the tail
+	store := newFakePartStore()
+	store.failPart = 2
+	stored, etags, err := writeParts(context.Background(), store, testUpload(4*TusPartSize, 0), bytes.NewReader(testBytes(0, 3*TusPartSize)))
+	if err == nil || stored != 2*TusPartSize || len(etags) != 1 {
+		t.Fatalf("writeParts = %d, %v, %v", stored, etags, err)
+	}
+	if !bytes.Equal(store.objects["obj.tail"], testBytes(TusPartSize, TusPartSize)) {
+		t.Fatalf("tail holds %d bytes, want the failed part", len(store.objects["obj.tail"]))
+	}
+
+	// A dropped connection keeps what was received
+	store = newFakePartStore()
+	stored, etags, err = writeParts(context.Background(), store, testUpload(4*TusPartSize, 0), brokenReader{bytes.NewReader(testBytes(0, TusPartSize+10))})
+	if err == nil || stored != TusPartSize+10 || len(etags) != 1 {
+		t.Fatalf("writeParts = %d, %v, %v", stored, etags, err)
+	}
+
+	// Without a tail only the full parts count
+	store = newFakePartStore()
+	store.failUpload = true
+	stored, etags, err = writeParts(context.Background(), store, testUpload(4*TusPartSize, 0), bytes.NewReader(testBytes(0, TusPartSize+10)))
+	if err == nil || stored != TusPartSize || len(etags) != 1 {
+		t.Fatalf("writeParts = %d, %v, %v", stored, etags, err)
+	}
+}
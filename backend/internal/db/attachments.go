@@ -0,0 +1,33 @@
+package db
+
+import (
+	"backend/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// migrateLegacyAttachments moves the single attachment once embedded in assets (attach_* columns)
+// into asset_attachments and drops the old columns
+func migrateLegacyAttachments(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Asset{}, "attach_file_name") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO asset_attachments (asset_id, position, file_name, file_size, file_hash, ipfs_cid, storage_path, storage_type)
+			SELECT id, 0, attach_file_name, attach_file_size, attach_file_hash, attach_ipfs_cid, attach_storage_path, attach_storage_type
+			FROM assets a
+			WHERE COALESCE(attach_file_name, '') <> ''
+			AND NOT EXISTS (SELECT 1 FROM asset_attachments t WHERE t.asset_id = a.id)`).Error
+		if err != nil {
+			return fmt.Errorf("failed to copy attachments: %w", err)
+		}
+		for _, column := range []string{"attach_file_name", "attach_file_size", "attach_file_hash", "attach_ipfs_cid", "attach_storage_path", "attach_storage_type"} {
+			if err := tx.Migrator().DropColumn(&models.Asset{}, column); err != nil {
+				return fmt.Errorf("failed to drop %s: %w", column, err)
+			}
+		}
+		return nil
+	})
+}
@@ -21,10 +21,13 @@ func InitDB(host, port, user, password, dbname string) (*gorm.DB, error) {
 	log.Println("Database connection established")
 
 	// Auto-migrate the schemas
-	err = db.AutoMigrate(&models.User{}, &models.Asset{}, &models.Notification{})
+	err = db.AutoMigrate(&models.User{}, &models.Asset{}, &models.AssetAttachment{}, &models.Notification{}, &models.EventCheckpoint{}, &models.WalletEntry{}, &models.Transaction{}, &models.IdempotencyKey{}, &models.TusUpload{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate: %v", err)
 	}
+	if err := migrateLegacyAttachments(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate attachments: %v", err)
+	}
 
 	log.Println("Database migration completed")
 	return db, nil
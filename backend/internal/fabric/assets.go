@@ -0,0 +1,173 @@
+package fabric
+
+import (
+	"backend/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// AssetQuery describes one page of a ledger asset listing. Empty filters are ignored.
+type AssetQuery struct {
+	OwnerID  string
+	Status   string
+	View     string
+	PageSize int32
+	Bookmark string
+}
+
+// HasFilter reports whether the query needs a CouchDB rich query instead of a range scan
+func (q AssetQuery) HasFilter() bool {
+	return q.OwnerID != "" || q.Status != "" || q.View != ""
+}
+
+// QueryAssets evaluates GetAssetsPaginated, or QueryAssetsPaginated when a filter is set
+func QueryAssets(contract *client.Contract, q AssetQuery) (*models.PaginatedQueryResult, error) {
+	pageSize := fmt.Sprintf("%d", q.PageSize)
+	if q.HasFilter() {
+		return evaluatePage(contract, "QueryAssetsPaginated", q.OwnerID, q.Status, q.View, pageSize, q.Bookmark)
+	}
+	return evaluatePage(contract, "GetAssetsPaginated", pageSize, q.Bookmark)
+}
+
+// QueryAllAssets follows the bookmarks of QueryAssets until the listing is exhausted
+func QueryAllAssets(contract *client.Contract, q AssetQuery) ([]models.LedgerValue, error) {
+	var records []models.LedgerValue
+	for {
+		page, err := QueryAssets(contract, q)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page.Records...)
+		if page.FetchedRecordsCount == 0 || page.Bookmark == "" || page.Bookmark == q.Bookmark {
+			return records, nil
+		}
+		q.Bookmark = page.Bookmark
+	}
+}
+
+// AssetEndorsers reads the owner and proposed owner of an asset from the ledger (not the
+// projection) into asset and returns the orgs that must endorse a write to it: callerOrg and the
+// orgs of both parties, which the asset's key-level policy may require. When the ledger can't be
+// read, asset is left untouched and only callerOrg endorses.
+func AssetEndorsers(contract *client.Contract, id string, callerOrg string, asset *models.Asset) []string {
+	endorsingOrgs := []string{callerOrg}
+	result, err := contract.EvaluateTransaction("ReadAsset", id)
+	if err != nil {
+		return endorsingOrgs
+	}
+	var val models.LedgerValue
+	if json.Unmarshal(result, &val) != nil {
+		return endorsingOrgs
+	}
+
+	asset.OwnerID = val.Asset.OwnerID
+	asset.ProposedOwnerID = val.Asset.ProposedOwnerID
+	for _, party := range []string{asset.OwnerID, asset.ProposedOwnerID} {
+		if org := MSPFromFullID(party); org != "" && !slices.Contains(endorsingOrgs, org) {
+			endorsingOrgs = append(endorsingOrgs, org)
+		}
+	}
+	return endorsingOrgs
+}
+
+// AssetsByOwner evaluates GetAssetsByOwner, which reads the chaincode's owner~asset index
+func AssetsByOwner(contract *client.Contract, ownerID string, pageSize int32, bookmark string) (*models.PaginatedQueryResult, error) {
+	return evaluatePage(contract, "GetAssetsByOwner", ownerID, fmt.Sprintf("%d", pageSize), bookmark)
+}
+
+// AssetsByStatus evaluates GetAssetsByStatus, which reads the chaincode's status~asset index
+func AssetsByStatus(contract *client.Contract, status string, pageSize int32, bookmark string) (*models.PaginatedQueryResult, error) {
+	return evaluatePage(contract, "GetAssetsByStatus", status, fmt.Sprintf("%d", pageSize), bookmark)
+}
+
+func evaluatePage(contract *client.Contract, name string, args ...string) (*models.PaginatedQueryResult, error) {
+	result, err := contract.EvaluateTransaction(name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var page models.PaginatedQueryResult
+	if err := json.Unmarshal(result, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse paginated assets: %w", err)
+	}
+	return &page, nil
+}
+
+// MSPFromFullID extracts the MSP ID from an "MSPID::username" identifier
+func MSPFromFullID(fullID string) string {
+	mspid, _, found := strings.Cut(fullID, "::")
+	if !found {
+		return ""
+	}
+	return mspid
+}
+
+// TransientAssetKey is the transient map key the chaincode reads private asset details from
+const TransientAssetKey = "asset_properties"
+
+// PrivateDetailsTransient builds the transient map for a PRIVATE asset, adding a random salt so the
+// hash published on the ledger can't be matched against guessed values
+func PrivateDetailsTransient(details models.AssetPrivateDetails) (map[string][]byte, error) {
+	if details.Salt == "" {
+		salt, err := newSalt()
+		if err != nil {
+			return nil, err
+		}
+		details.Salt = salt
+	}
+
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{TransientAssetKey: detailsJSON}, nil
+}
+
+// TransientMetadataKey is the transient map key the chaincode reads metadata updates from
+const TransientMetadataKey = "asset_metadata"
+
+// MetadataUpdateTransient builds the transient map for UpdateAssetMetadata. Sending the update as
+// transient data keeps changes to PRIVATE assets off the proposal; the fresh salt re-seals them.
+func MetadataUpdateTransient(update models.AssetMetadataUpdate) (map[string][]byte, error) {
+	if update.Salt == "" {
+		salt, err := newSalt()
+		if err != nil {
+			return nil, err
+		}
+		update.Salt = salt
+	}
+
+	updateJSON, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{TransientMetadataKey: updateJSON}, nil
+}
+
+// TransientAttachmentKey is the transient map key the chaincode reads AddAttachment and
+// ReplaceAttachment attachments from
+const TransientAttachmentKey = "asset_attachment"
+
+// AttachmentTransient builds the transient map for AddAttachment and ReplaceAttachment, keeping
+// the files of PRIVATE assets off the proposal
+func AttachmentTransient(attachment models.AssetAttachment) (map[string][]byte, error) {
+	attachmentJSON, err := json.Marshal(attachment)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{TransientAttachmentKey: attachmentJSON}, nil
+}
+
+func newSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hex.EncodeToString(salt), nil
+}
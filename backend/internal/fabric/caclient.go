@@ -0,0 +1,444 @@
+package fabric
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CAAttribute is an attribute attached to a CA identity; ECert attributes are embedded in enrollment certificates
+type CAAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	ECert bool   `json:"ecert,omitempty"`
+}
+
+// CAIdentity is a registered identity as returned by /api/v1/identities
+type CAIdentity struct {
+	ID             string        `json:"id"`
+	Type           string        `json:"type"`
+	Affiliation    string        `json:"affiliation"`
+	Attributes     []CAAttribute `json:"attrs"`
+	MaxEnrollments int           `json:"max_enrollments"`
+}
+
+// Attribute returns the value of the named attribute ("" when absent)
+func (i CAIdentity) Attribute(name string) string {
+	for _, attr := range i.Attributes {
+		if attr.Name == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// RegistrationRequest is the body of /api/v1/register
+type RegistrationRequest struct {
+	Name           string        `json:"id"`
+	Type           string        `json:"type"`
+	Secret         string        `json:"secret,omitempty"`
+	MaxEnrollments int           `json:"max_enrollments,omitempty"`
+	Affiliation    string        `json:"affiliation"`
+	Attributes     []CAAttribute `json:"attrs,omitempty"`
+	CAName         string        `json:"caname,omitempty"`
+}
+
+// ModifyIdentityRequest is the body of PUT /api/v1/identities/{id}. Empty fields are left unchanged
+// and attributes are merged into the identity's existing ones.
+type ModifyIdentityRequest struct {
+	Type           string        `json:"type,omitempty"`
+	Affiliation    string        `json:"affiliation,omitempty"`
+	Attributes     []CAAttribute `json:"attrs,omitempty"`
+	MaxEnrollments int           `json:"max_enrollments,omitempty"`
+	Secret         string        `json:"secret,omitempty"`
+	CAName         string        `json:"caname,omitempty"`
+}
+
+// RevocationRequest is the body of /api/v1/revoke. Either Name or Serial+AKI selects what to revoke.
+type RevocationRequest struct {
+	Name   string `json:"id,omitempty"`
+	Serial string `json:"serial,omitempty"`
+	AKI    string `json:"aki,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	GenCRL bool   `json:"gencrl,omitempty"`
+	CAName string `json:"caname,omitempty"`
+}
+
+// RevokedCert identifies a certificate revoked by /api/v1/revoke
+type RevokedCert struct {
+	Serial string `json:"Serial"`
+	AKI    string `json:"AKI"`
+}
+
+// RevocationResponse lists the revoked certificates and, when requested, the CA's new PEM CRL
+type RevocationResponse struct {
+	RevokedCerts []RevokedCert `json:"RevokedCerts"`
+	CRL          []byte        `json:"CRL"`
+}
+
+// caResponse is the envelope every Fabric CA endpoint replies with
+type caResponse struct {
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Errors  []caError       `json:"errors"`
+}
+
+type caError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// CAError is a failure reported by the CA itself (as opposed to a transport error)
+type CAError struct {
+	StatusCode int
+	Errors     []caError
+}
+
+func (e *CAError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("CA request failed (status %d)", e.StatusCode)
+	}
+	return fmt.Sprintf("CA request failed (status %d): %s", e.StatusCode, e.Errors[0].Message)
+}
+
+// caCredentials is the certificate/key pair a request is signed with
+type caCredentials struct {
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// CAClient talks to a Fabric CA server over its REST API. Registrar operations are signed
+// with the registrar identity from the wallet, which is enrolled on first use.
+type CAClient struct {
+	cfg        CAConfig
+	httpClient *http.Client
+}
+
+// NewCAClient creates a REST client for the CA described by cfg
+func NewCAClient(cfg CAConfig) *CAClient {
+	return &CAClient{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// Enroll requests a certificate for username with its enrollment secret and saves it to the wallet
+func (c *CAClient) Enroll(username, secret string) error {
+	key, csrPEM, err := newCSR(username)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"certificate_request": string(csrPEM),
+		"profile":             "tls", // Standard profile
+		"label":               "",
+		"caname":              c.cfg.CAName,
+	}
+	var result enrollmentResult
+	err = c.do(http.MethodPost, "/api/v1/enroll", body, func(req *http.Request, _ []byte) error {
+		req.SetBasicAuth(username, secret)
+		return nil
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("enrollment failed: %w", err)
+	}
+
+	return c.saveEnrollment(username, key, result)
+}
+
+// Reenroll renews username's certificate using its current wallet credentials, keeping the
+// same CA identity and attributes but issuing a fresh key and expiry
+func (c *CAClient) Reenroll(username string) error {
+	creds, err := loadCredentials(username, c.cfg.MSPID, c.cfg.Wallet)
+	if err != nil {
+		return err
+	}
+
+	key, csrPEM, err := newCSR(username)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"certificate_request": string(csrPEM),
+		"caname":              c.cfg.CAName,
+	}
+	var result enrollmentResult
+	if err := c.do(http.MethodPost, "/api/v1/reenroll", body, creds.sign, &result); err != nil {
+		return fmt.Errorf("reenrollment failed: %w", err)
+	}
+
+	return c.saveEnrollment(username, key, result)
+}
+
+// Register registers a new identity and returns its enrollment secret
+func (c *CAClient) Register(req RegistrationRequest) (string, error) {
+	registrar, err := c.registrar()
+	if err != nil {
+		return "", err
+	}
+	if req.CAName == "" {
+		req.CAName = c.cfg.CAName
+	}
+
+	var result struct {
+		Secret string `json:"secret"`
+	}
+	if err := c.do(http.MethodPost, "/api/v1/register", req, registrar.sign, &result); err != nil {
+		return "", err
+	}
+	return result.Secret, nil
+}
+
+// ListIdentities returns every identity the registrar is allowed to see
+func (c *CAClient) ListIdentities() ([]CAIdentity, error) {
+	registrar, err := c.registrar()
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Identities []CAIdentity `json:"identities"`
+	}
+	path := "/api/v1/identities?ca=" + url.QueryEscape(c.cfg.CAName)
+	if err := c.do(http.MethodGet, path, nil, registrar.sign, &result); err != nil {
+		return nil, err
+	}
+	return result.Identities, nil
+}
+
+// GetIdentity returns a single registered identity
+func (c *CAClient) GetIdentity(id string) (*CAIdentity, error) {
+	registrar, err := c.registrar()
+	if err != nil {
+		return nil, err
+	}
+
+	var result CAIdentity
+	path := "/api/v1/identities/" + url.PathEscape(id) + "?ca=" + url.QueryEscape(c.cfg.CAName)
+	if err := c.do(http.MethodGet, path, nil, registrar.sign, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ModifyIdentity updates a registered identity
+func (c *CAClient) ModifyIdentity(id string, req ModifyIdentityRequest) error {
+	registrar, err := c.registrar()
+	if err != nil {
+		return err
+	}
+	if req.CAName == "" {
+		req.CAName = c.cfg.CAName
+	}
+
+	return c.do(http.MethodPut, "/api/v1/identities/"+url.PathEscape(id), req, registrar.sign, nil)
+}
+
+// Revoke revokes an identity (all of its certificates) or a single certificate
+func (c *CAClient) Revoke(req RevocationRequest) (*RevocationResponse, error) {
+	registrar, err := c.registrar()
+	if err != nil {
+		return nil, err
+	}
+	if req.CAName == "" {
+		req.CAName = c.cfg.CAName
+	}
+
+	var result RevocationResponse
+	if err := c.do(http.MethodPost, "/api/v1/revoke", req, registrar.sign, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GenCRL returns the CA's current PEM CRL covering all revoked certificates
+func (c *CAClient) GenCRL() ([]byte, error) {
+	registrar, err := c.registrar()
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		CRL []byte `json:"CRL"`
+	}
+	req := map[string]string{"caname": c.cfg.CAName}
+	if err := c.do(http.MethodPost, "/api/v1/gencrl", req, registrar.sign, &result); err != nil {
+		return nil, err
+	}
+	return result.CRL, nil
+}
+
+// registrar loads the registrar's credentials from the wallet, enrolling it first if needed
+func (c *CAClient) registrar() (*caCredentials, error) {
+	if !IdentityExists(c.cfg.RegistrarID, c.cfg.MSPID, c.cfg.Wallet) {
+		if err := c.Enroll(c.cfg.RegistrarID, c.cfg.RegistrarSecret); err != nil {
+			return nil, fmt.Errorf("failed to enroll registrar %s: %w", c.cfg.RegistrarID, err)
+		}
+	}
+	return loadCredentials(c.cfg.RegistrarID, c.cfg.MSPID, c.cfg.Wallet)
+}
+
+// do sends a JSON request, lets authorize sign it and decodes the CA envelope's result into out
+func (c *CAClient) do(method, path string, body interface{}, authorize func(*http.Request, []byte) error, out interface{}) error {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.cfg.URL+path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := authorize(req, bodyBytes); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call CA: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read CA response: %v", err)
+	}
+
+	var envelope caResponse
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("failed to decode CA response (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if !envelope.Success || resp.StatusCode >= 300 {
+		return &CAError{StatusCode: resp.StatusCode, Errors: envelope.Errors}
+	}
+
+	if out == nil || len(envelope.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("failed to decode CA result: %v", err)
+	}
+	return nil
+}
+
+// sign sets the Fabric CA token: <b64 cert>.<b64 ECDSA signature over
+// method.b64(uri).b64(body).b64(cert)>
+func (cred *caCredentials) sign(req *http.Request, body []byte) error {
+	b64Cert := base64.StdEncoding.EncodeToString(cred.certPEM)
+	payload := req.Method + "." +
+		base64.StdEncoding.EncodeToString([]byte(req.URL.RequestURI())) + "." +
+		base64.StdEncoding.EncodeToString(body) + "." +
+		b64Cert
+	digest := sha256.Sum256([]byte(payload))
+
+	r, s, err := ecdsa.Sign(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign CA request: %v", err)
+	}
+	// Fabric rejects high-S signatures
+	halfOrder := new(big.Int).Rsh(cred.key.Params().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(cred.key.Params().N, s)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", b64Cert+"."+base64.StdEncoding.EncodeToString(signature))
+	return nil
+}
+
+type enrollmentResult struct {
+	Cert string `json:"Cert"`
+}
+
+// saveEnrollment decodes the issued certificate and stores it with its key in the wallet
+func (c *CAClient) saveEnrollment(username string, key *ecdsa.PrivateKey, result enrollmentResult) error {
+	certPEM, err := base64.StdEncoding.DecodeString(result.Cert)
+	if err != nil || len(certPEM) == 0 {
+		certPEM = []byte(result.Cert)
+	}
+	if len(certPEM) == 0 {
+		return fmt.Errorf("received empty certificate from CA")
+	}
+
+	x509Encoded, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: x509Encoded})
+
+	return SaveIdentity(username, certPEM, keyPEM, c.cfg.Wallet, c.cfg.MSPID)
+}
+
+// newCSR generates a P-256 key and a PEM certificate request for username
+func newCSR(username string) (*ecdsa.PrivateKey, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	template := x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName: username,
+		},
+	}
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSR: %v", err)
+	}
+	return privateKey, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes}), nil
+}
+
+// loadCredentials reads a wallet identity's certificate and ECDSA key for signing CA requests
+func loadCredentials(username string, mspid string, wallet Wallet) (*caCredentials, error) {
+	stored, err := wallet.Get(mspid, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s from wallet: %w", username, err)
+	}
+	certPEM, keyPEM := stored.Certificate, stored.PrivateKey
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid key PEM for %s", username)
+	}
+	var key *ecdsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key for %s is not an ECDSA key", username)
+		}
+		key = ecKey
+	} else if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("failed to parse key for %s: %v", username, err)
+	}
+
+	return &caCredentials{certPEM: certPEM, key: key}, nil
+}
@@ -0,0 +1,57 @@
+package fabric
+
+import (
+	"backend/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"gorm.io/gorm"
+)
+
+// DBCheckpointer is a client.Checkpoint persisted in Postgres. Checkpoints are written in the same
+// database transaction as the projected asset, so a crash can never record progress without the data.
+type DBCheckpointer struct {
+	db    *gorm.DB
+	state models.EventCheckpoint
+}
+
+// NewDBCheckpointer loads the named checkpoint, creating an empty one on first start
+func NewDBCheckpointer(db *gorm.DB, name string) (*DBCheckpointer, error) {
+	state := models.EventCheckpoint{Name: name}
+	err := db.Where("name = ?", name).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := db.Create(&state).Error; err != nil {
+			return nil, fmt.Errorf("failed to create checkpoint %s: %w", name, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint %s: %w", name, err)
+	}
+
+	return &DBCheckpointer{db: db, state: state}, nil
+}
+
+// BlockNumber in which the next event is expected
+func (c *DBCheckpointer) BlockNumber() uint64 {
+	return c.state.BlockNumber
+}
+
+// TransactionID of the last successfully processed event within the current block
+func (c *DBCheckpointer) TransactionID() string {
+	return c.state.TransactionID
+}
+
+// CheckpointChaincodeEvent records a processed event using the caller's database transaction
+func (c *DBCheckpointer) CheckpointChaincodeEvent(tx *gorm.DB, event *client.ChaincodeEvent) error {
+	next := c.state
+	next.BlockNumber = event.BlockNumber
+	next.TransactionID = event.TransactionID
+
+	if err := tx.Save(&next).Error; err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	c.state = next
+	return nil
+}
+
+var _ client.Checkpoint = (*DBCheckpointer)(nil)
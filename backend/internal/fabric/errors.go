@@ -0,0 +1,139 @@
+package fabric
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error codes returned by the chaincode (see chaincode/errors.go) and by ParseContractError
+const (
+	CodeInvalidArgument  = "INVALID_ARGUMENT"
+	CodeNotFound         = "NOT_FOUND"
+	CodePermissionDenied = "PERMISSION_DENIED"
+	CodeConflict         = "CONFLICT"
+	CodeAlreadyExists    = "ALREADY_EXISTS"
+	// Codes for failures outside the chaincode
+	CodeUnavailable = "UNAVAILABLE"
+	CodeTimeout     = "TIMEOUT"
+	CodeInternal    = "INTERNAL"
+	// CodeEndorsementFailed is a transaction invalidated by its endorsement policy, which means the
+	// backend asked the wrong orgs to endorse; it is a server error, not a lack of authority
+	CodeEndorsementFailed = "ENDORSEMENT_FAILED"
+)
+
+// ContractError is the classified outcome of a failed Evaluate or Submit
+type ContractError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// TxID is set when the failure belongs to a submitted transaction
+	TxID string `json:"tx_id,omitempty"`
+	// Details are the per-peer messages attached by the gateway
+	Details []string `json:"details,omitempty"`
+}
+
+func (e *ContractError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// chaincodeError is the JSON body of a structured chaincode error
+type chaincodeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ParseContractError unwraps an error from the gateway client. Structured chaincode errors
+// found in the gRPC status or its ErrorDetails keep their code; invalidated transactions are
+// conflicts; peer and orderer outages are UNAVAILABLE or TIMEOUT; anything else is INTERNAL.
+func ParseContractError(err error) *ContractError {
+	if err == nil {
+		return nil
+	}
+	var parsed *ContractError
+	if errors.As(err, &parsed) {
+		return parsed
+	}
+
+	result := &ContractError{Code: CodeInternal, Message: err.Error()}
+
+	var txErr *client.TransactionError
+	if errors.As(err, &txErr) {
+		result.TxID = txErr.TransactionID
+	}
+
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		return invalidTransactionError(commitErr.TransactionID, commitErr.Code)
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return result
+	}
+	result.Message = st.Message()
+
+	messages := []string{st.Message()}
+	for _, detail := range st.Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			result.Details = append(result.Details, fmt.Sprintf("%s (%s): %s", errorDetail.GetAddress(), errorDetail.GetMspId(), errorDetail.GetMessage()))
+			messages = append(messages, errorDetail.GetMessage())
+		}
+	}
+	for _, message := range messages {
+		if ccErr, ok := findChaincodeError(message); ok {
+			result.Code = ccErr.Code
+			result.Message = ccErr.Message
+			return result
+		}
+	}
+
+	switch st.Code() {
+	case codes.Unavailable:
+		result.Code = CodeUnavailable
+	case codes.DeadlineExceeded:
+		result.Code = CodeTimeout
+	case codes.Aborted:
+		// e.g. endorsements from different peers that don't match
+		result.Code = CodeConflict
+	}
+	return result
+}
+
+// invalidTransactionError describes a transaction that was ordered but invalidated, typically
+// by a concurrent update of the same key
+func invalidTransactionError(txID string, code peer.TxValidationCode) *ContractError {
+	result := &ContractError{
+		Code:    CodeInternal,
+		Message: fmt.Sprintf("transaction was not committed: %s", code),
+		TxID:    txID,
+	}
+	switch code {
+	case peer.TxValidationCode_MVCC_READ_CONFLICT, peer.TxValidationCode_PHANTOM_READ_CONFLICT, peer.TxValidationCode_DUPLICATE_TXID:
+		result.Code = CodeConflict
+	case peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE:
+		result.Code = CodeEndorsementFailed
+	}
+	return result
+}
+
+// findChaincodeError locates the JSON error object inside a peer message such as
+// "chaincode response 500, {"code":"NOT_FOUND","message":"asset a1 does not exist"}"
+func findChaincodeError(message string) (chaincodeError, bool) {
+	start := strings.Index(message, `{"code":`)
+	if start < 0 {
+		return chaincodeError{}, false
+	}
+
+	var ccErr chaincodeError
+	if err := json.NewDecoder(strings.NewReader(message[start:])).Decode(&ccErr); err != nil || ccErr.Code == "" {
+		return chaincodeError{}, false
+	}
+	return ccErr, true
+}
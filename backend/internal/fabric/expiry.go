@@ -0,0 +1,195 @@
+package fabric
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// ExpiryMonitorConfig configures the wallet certificate expiry monitor
+type ExpiryMonitorConfig struct {
+	CAConfigs []CAConfig
+	// Interval between wallet scans
+	Interval time.Duration
+	// Window is how long before NotAfter an identity is reported and re-enrolled
+	Window time.Duration
+	// AutoRenew re-enrolls identities inside the window through the CA's /reenroll API
+	AutoRenew bool
+}
+
+// IdentityExpiry describes one wallet identity's certificate lifetime
+type IdentityExpiry struct {
+	Username      string    `json:"username"`
+	MSPID         string    `json:"mspid"`
+	NotAfter      time.Time `json:"not_after"`
+	Expired       bool      `json:"expired"`
+	LastRenewedAt time.Time `json:"last_renewed_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// ExpiryMonitor periodically scans the wallet certificates of every organization and re-enrolls identities
+// whose certificates are about to expire, so users keep the ability to transact.
+// Already expired certificates cannot be re-enrolled; those users are enrolled again at their next login.
+type ExpiryMonitor struct {
+	cfg ExpiryMonitorConfig
+
+	mu       sync.RWMutex
+	expiring []IdentityExpiry
+	renewals map[string]IdentityExpiry
+	lastScan time.Time
+}
+
+// NewExpiryMonitor creates a monitor; call Run to start it
+func NewExpiryMonitor(cfg ExpiryMonitorConfig) *ExpiryMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 30 * 24 * time.Hour
+	}
+	return &ExpiryMonitor{cfg: cfg, renewals: map[string]IdentityExpiry{}}
+}
+
+// Run scans the wallet every Interval until ctx is cancelled
+func (m *ExpiryMonitor) Run(ctx context.Context) {
+	log.Printf("Starting certificate expiry monitor (window %s, auto-renew %t)...", m.cfg.Window, m.cfg.AutoRenew)
+	for {
+		m.Scan()
+
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping certificate expiry monitor...")
+			return
+		case <-time.After(m.cfg.Interval):
+		}
+	}
+}
+
+// Scan checks every wallet identity once, re-enrolling those inside the window when AutoRenew is set
+func (m *ExpiryMonitor) Scan() {
+	var expiring []IdentityExpiry
+	for _, caCfg := range m.cfg.CAConfigs {
+		identities, err := WalletExpiries(caCfg.MSPID, caCfg.Wallet)
+		if err != nil {
+			log.Printf("Warning: failed to scan %s wallet: %v", caCfg.MSPID, err)
+			continue
+		}
+
+		for _, id := range identities {
+			if time.Until(id.NotAfter) > m.cfg.Window {
+				continue
+			}
+			if m.cfg.AutoRenew && !id.Expired {
+				renewed, err := m.renew(caCfg, id.Username)
+				if err == nil {
+					log.Printf("Re-enrolled %s (%s), certificate now valid until %s", id.Username, id.MSPID, renewed.NotAfter.Format(time.RFC3339))
+					continue
+				}
+				log.Printf("Warning: failed to re-enroll %s (%s): %v", id.Username, id.MSPID, err)
+			}
+			expiring = append(expiring, m.withRenewal(id))
+		}
+	}
+
+	m.mu.Lock()
+	m.expiring = expiring
+	m.lastScan = time.Now()
+	m.mu.Unlock()
+}
+
+// Expiring returns the identities found inside the window by the last scan
+func (m *ExpiryMonitor) Expiring() ([]IdentityExpiry, time.Time) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]IdentityExpiry{}, m.expiring...), m.lastScan
+}
+
+// Window returns the configured expiry window
+func (m *ExpiryMonitor) Window() time.Duration {
+	return m.cfg.Window
+}
+
+// Renew re-enrolls a single wallet identity on demand
+func (m *ExpiryMonitor) Renew(mspid, username string) (IdentityExpiry, error) {
+	for _, caCfg := range m.cfg.CAConfigs {
+		if caCfg.MSPID == mspid {
+			return m.renew(caCfg, username)
+		}
+	}
+	return IdentityExpiry{}, fmt.Errorf("unknown organization %s", mspid)
+}
+
+func (m *ExpiryMonitor) renew(caCfg CAConfig, username string) (IdentityExpiry, error) {
+	key := caCfg.MSPID + "/" + username
+	err := NewCAClient(caCfg).Reenroll(username)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.renewals[key]
+	if err != nil {
+		record.LastError = err.Error()
+		m.renewals[key] = record
+		return IdentityExpiry{}, err
+	}
+
+	renewed, err := identityExpiry(username, caCfg.MSPID, caCfg.Wallet)
+	if err != nil {
+		return IdentityExpiry{}, err
+	}
+	renewed.LastRenewedAt = time.Now()
+	m.renewals[key] = renewed
+	return renewed, nil
+}
+
+// withRenewal annotates an identity with the outcome of its last renewal attempt
+func (m *ExpiryMonitor) withRenewal(id IdentityExpiry) IdentityExpiry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record := m.renewals[id.MSPID+"/"+id.Username]
+	id.LastRenewedAt = record.LastRenewedAt
+	id.LastError = record.LastError
+	return id
+}
+
+// WalletExpiries reads the certificate expiry of every identity an org has in the wallet
+func WalletExpiries(mspid string, wallet Wallet) ([]IdentityExpiry, error) {
+	usernames, err := wallet.List(mspid)
+	if err != nil {
+		return nil, err
+	}
+
+	var identities []IdentityExpiry
+	for _, username := range usernames {
+		id, err := identityExpiry(username, mspid, wallet)
+		if err != nil {
+			log.Printf("Warning: skipping wallet identity %s (%s): %v", username, mspid, err)
+			continue
+		}
+		identities = append(identities, id)
+	}
+
+	sort.Slice(identities, func(i, j int) bool { return identities[i].NotAfter.Before(identities[j].NotAfter) })
+	return identities, nil
+}
+
+func identityExpiry(username string, mspid string, wallet Wallet) (IdentityExpiry, error) {
+	stored, err := wallet.Get(mspid, username)
+	if err != nil {
+		return IdentityExpiry{}, err
+	}
+	cert, err := identity.CertificateFromPEM(stored.Certificate)
+	if err != nil {
+		return IdentityExpiry{}, err
+	}
+	return IdentityExpiry{
+		Username: username,
+		MSPID:    mspid,
+		NotAfter: cert.NotAfter,
+		Expired:  time.Now().After(cert.NotAfter),
+	}, nil
+}
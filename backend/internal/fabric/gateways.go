@@ -0,0 +1,232 @@
+package fabric
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// GatewayPoolConfig configures the per-identity gateway cache
+type GatewayPoolConfig struct {
+	ChannelName   string
+	ChaincodeName string
+	// MaxSize bounds the number of cached gateways; the least recently used one is evicted first
+	MaxSize int
+	// TTL after which a gateway is rebuilt from the wallet on its next use
+	TTL time.Duration
+}
+
+// GatewayPool caches one client.Gateway per (mspid, username), connected to a peer of the
+// identity's own organization, so requests don't reload and parse wallet credentials every time.
+// Gateways are handed out as leases; an evicted gateway is only closed once its last lease is
+// released, because closing a gateway cancels the calls still running on it.
+type GatewayPool struct {
+	cfg    GatewayPoolConfig
+	peers  *PeerNetwork
+	wallet Wallet
+
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	lru         *list.List
+	generations map[string]uint64
+}
+
+type pooledGateway struct {
+	key     string
+	gateway *client.Gateway
+	created time.Time
+	refs    int
+	evicted bool
+}
+
+// GatewayLease is a gateway borrowed from the pool; Close returns it instead of closing the gateway
+type GatewayLease struct {
+	*client.Gateway
+	pool     *GatewayPool
+	entry    *pooledGateway
+	contract *client.Contract
+	once     sync.Once
+}
+
+// NewGatewayPool creates an empty pool reading identities from wallet
+func NewGatewayPool(peers *PeerNetwork, wallet Wallet, cfg GatewayPoolConfig) *GatewayPool {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 256
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Minute
+	}
+	return &GatewayPool{
+		cfg:         cfg,
+		peers:       peers,
+		wallet:      wallet,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		generations: map[string]uint64{},
+	}
+}
+
+// Acquire returns a lease on the gateway for username@mspid, creating it from the wallet if it
+// is not cached or has outlived the TTL. Callers must Close the lease.
+func (p *GatewayPool) Acquire(mspid, username string) (*GatewayLease, error) {
+	key := gatewayKey(mspid, username)
+
+	p.mu.Lock()
+	if element, ok := p.entries[key]; ok {
+		entry := element.Value.(*pooledGateway)
+		if time.Since(entry.created) < p.cfg.TTL {
+			p.lru.MoveToFront(element)
+			entry.refs++
+			p.mu.Unlock()
+			return p.lease(entry), nil
+		}
+		p.removeLocked(element)
+	}
+	generation := p.generations[key]
+	p.mu.Unlock()
+
+	// Build outside the lock: loading from the wallet may hit the disk or the database
+	id, sign, err := GetIdentity(username, mspid, p.wallet)
+	if err != nil {
+		return nil, err
+	}
+	gateway, err := CreateGateway(p.peers.Conn(mspid), id, sign)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := &pooledGateway{key: key, gateway: gateway, created: time.Now(), refs: 1}
+	if p.generations[key] != generation {
+		// The identity was invalidated while we loaded it: serve this request, but don't cache it
+		entry.evicted = true
+		return p.lease(entry), nil
+	}
+	if element, ok := p.entries[key]; ok {
+		// A concurrent request cached a gateway first
+		p.removeLocked(element)
+	}
+	p.entries[key] = p.lru.PushFront(entry)
+	for p.lru.Len() > p.cfg.MaxSize {
+		p.removeLocked(p.lru.Back())
+	}
+	return p.lease(entry), nil
+}
+
+// Contract returns a lease together with the configured chaincode contract
+func (p *GatewayPool) Contract(mspid, username string) (*GatewayLease, *client.Contract, error) {
+	lease, err := p.Acquire(mspid, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lease, lease.contract, nil
+}
+
+// Invalidate drops the cached gateway of username@mspid, e.g. after its certificate was
+// revoked or re-enrolled; the next Acquire loads the new credentials
+func (p *GatewayPool) Invalidate(mspid, username string) {
+	key := gatewayKey(mspid, username)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.generations[key]++
+	if element, ok := p.entries[key]; ok {
+		p.removeLocked(element)
+	}
+}
+
+// Wallet wraps w so that every Put or Remove invalidates the affected identity's gateway.
+// Hand the wrapped wallet to everything that enrolls, re-enrolls or revokes identities.
+func (p *GatewayPool) Wallet() Wallet {
+	return &invalidatingWallet{Wallet: p.wallet, pool: p}
+}
+
+// Size returns the number of cached gateways
+func (p *GatewayPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
+
+// Close evicts every cached gateway
+func (p *GatewayPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.lru.Len() > 0 {
+		p.removeLocked(p.lru.Back())
+	}
+}
+
+func (p *GatewayPool) lease(entry *pooledGateway) *GatewayLease {
+	network := entry.gateway.GetNetwork(p.cfg.ChannelName)
+	return &GatewayLease{
+		Gateway:  entry.gateway,
+		pool:     p,
+		entry:    entry,
+		contract: network.GetContract(p.cfg.ChaincodeName),
+	}
+}
+
+// removeLocked takes an entry out of the cache, closing its gateway unless it is still leased
+func (p *GatewayPool) removeLocked(element *list.Element) {
+	entry := element.Value.(*pooledGateway)
+	p.lru.Remove(element)
+	delete(p.entries, entry.key)
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.gateway.Close()
+	}
+}
+
+func (p *GatewayPool) release(entry *pooledGateway) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.gateway.Close()
+	}
+}
+
+// Close returns the lease to the pool; calling it more than once is harmless
+func (l *GatewayLease) Close() error {
+	l.once.Do(func() { l.pool.release(l.entry) })
+	return nil
+}
+
+// Retain returns a second lease on the same gateway, for work that outlives the request holding l
+// (such as waiting for a commit status). Both leases must be closed.
+func (l *GatewayLease) Retain() *GatewayLease {
+	l.pool.mu.Lock()
+	l.entry.refs++
+	l.pool.mu.Unlock()
+	return l.pool.lease(l.entry)
+}
+
+// Contract returns the pool's configured chaincode contract on this gateway
+func (l *GatewayLease) Contract() *client.Contract {
+	return l.contract
+}
+
+func gatewayKey(mspid, username string) string {
+	return mspid + "/" + username
+}
+
+// invalidatingWallet evicts cached gateways whenever an identity's credentials change
+type invalidatingWallet struct {
+	Wallet
+	pool *GatewayPool
+}
+
+func (w *invalidatingWallet) Put(mspid, username string, id *WalletIdentity) error {
+	defer w.pool.Invalidate(mspid, username)
+	return w.Wallet.Put(mspid, username, id)
+}
+
+func (w *invalidatingWallet) Remove(mspid, username string) error {
+	defer w.pool.Invalidate(mspid, username)
+	return w.Wallet.Remove(mspid, username)
+}
@@ -4,72 +4,325 @@ import (
 	"backend/internal/models"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
 )
 
-// StartEventListener connects to the blockchain and listens for events to sync the database
-func StartEventListener(ctx context.Context, network *client.Network, db *gorm.DB) {
-	log.Println("Starting Eventual Consistency Listener...")
+// ListenerCheckpointName identifies the projection listener's row in event_checkpoints
+const ListenerCheckpointName = "asset-projection"
 
-	blocks, err := network.BlockEvents(ctx)
-	if err != nil {
-		log.Printf("Failed to subscribe to block events: %v", err)
-		return
+// assetEvents are the chaincode events whose payload is the full LedgerValue of the affected asset
+var assetEvents = map[string]bool{
+	"CreateAsset":         true,
+	"ProposeTransfer":     true,
+	"AcceptTransfer":      true,
+	"RejectTransfer":      true,
+	"CancelTransfer":      true,
+	"ExpireTransfer":      true,
+	"UpdateAssetStatus":   true,
+	"UpdateAssetView":     true,
+	"UpdateAssetMetadata": true,
+	"AddAttachment":       true,
+	"ReplaceAttachment":   true,
+	"RemoveAttachment":    true,
+	"DeleteAsset":         true,
+}
+
+// ListenerConfig configures the supervised projection listener
+type ListenerConfig struct {
+	ChannelName   string
+	ChaincodeName string
+	// Identity used to subscribe, loaded from the wallet on every (re)connect
+	Username string
+	MSPID    string
+	Wallet   Wallet
+	// CheckpointFile switches from the Postgres checkpoint to a client.FileCheckpointer
+	CheckpointFile string
+	MinBackoff     time.Duration
+	MaxBackoff     time.Duration
+}
+
+// ListenerStatus is the health snapshot reported on the admin API
+type ListenerStatus struct {
+	Running         bool      `json:"running"`
+	Connected       bool      `json:"connected"`
+	Checkpoint      string    `json:"checkpoint"`
+	CheckpointBlock uint64    `json:"checkpoint_block"`
+	CheckpointTxID  string    `json:"checkpoint_tx_id"`
+	ChainHeight     uint64    `json:"chain_height"`
+	Lag             uint64    `json:"lag"`
+	LastEventAt     time.Time `json:"last_event_at"`
+	LastError       string    `json:"last_error"`
+	LastErrorAt     time.Time `json:"last_error_at"`
+	Restarts        int       `json:"restarts"`
+}
+
+// EventCheckpointer is a client.Checkpoint the listener advances after applying each event
+type EventCheckpointer interface {
+	client.Checkpoint
+	CheckpointChaincodeEvent(tx *gorm.DB, event *client.ChaincodeEvent) error
+}
+
+// EventListener keeps the off-chain database in sync with chaincode events. It reconnects with
+// exponential backoff and resumes from its checkpoint instead of replaying the ledger.
+type EventListener struct {
+	cfg   ListenerConfig
+	peers *PeerNetwork
+	db    *gorm.DB
+
+	mu      sync.RWMutex
+	status  ListenerStatus
+	network *client.Network
+}
+
+// NewEventListener creates a listener; call Run to start it
+func NewEventListener(cfg ListenerConfig, peers *PeerNetwork, db *gorm.DB) *EventListener {
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = time.Minute
+	}
+
+	checkpoint := "postgres"
+	if cfg.CheckpointFile != "" {
+		checkpoint = "file"
 	}
 
+	return &EventListener{
+		cfg:    cfg,
+		peers:  peers,
+		db:     db,
+		status: ListenerStatus{Checkpoint: checkpoint},
+	}
+}
+
+// Run supervises the event subscription until ctx is cancelled
+func (l *EventListener) Run(ctx context.Context) {
+	log.Println("Starting Eventual Consistency Listener...")
+	l.update(func(s *ListenerStatus) { s.Running = true })
+	defer l.update(func(s *ListenerStatus) { s.Running = false; s.Connected = false })
+
+	backoff := l.cfg.MinBackoff
 	for {
+		started := time.Now()
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			log.Println("Stopping event listener...")
+			return
+		}
+
+		// A session that stayed up longer than the max backoff counts as healthy
+		if time.Since(started) > l.cfg.MaxBackoff {
+			backoff = l.cfg.MinBackoff
+		}
+
+		log.Printf("Listener Error: %v (reconnecting in %s)", err, backoff)
+		l.update(func(s *ListenerStatus) {
+			s.Connected = false
+			s.LastError = err.Error()
+			s.LastErrorAt = time.Now()
+			s.Restarts++
+		})
+
 		select {
 		case <-ctx.Done():
 			log.Println("Stopping event listener...")
 			return
-		case block := <-blocks:
-			if block == nil {
-				continue
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > l.cfg.MaxBackoff {
+			backoff = l.cfg.MaxBackoff
+		}
+	}
+}
+
+// listen runs one subscription session and returns when it fails
+func (l *EventListener) listen(ctx context.Context) error {
+	id, sign, err := GetIdentity(l.cfg.Username, l.cfg.MSPID, l.cfg.Wallet)
+	if err != nil {
+		return fmt.Errorf("could not load %s identity: %w", l.cfg.Username, err)
+	}
+
+	// Each session picks the preferred healthy peer, so a reconnect moves off a failed one
+	gw, err := CreateGateway(l.peers.Conn(l.cfg.MSPID), id, sign)
+	if err != nil {
+		return err
+	}
+	defer gw.Close()
+
+	checkpointer, closeCheckpointer, err := l.openCheckpointer()
+	if err != nil {
+		return err
+	}
+	defer closeCheckpointer()
+
+	network := gw.GetNetwork(l.cfg.ChannelName)
+
+	// A fresh checkpoint replays from the genesis block to build the projection from scratch
+	options := []client.ChaincodeEventsOption{client.WithCheckpoint(checkpointer)}
+	if checkpointer.BlockNumber() == 0 && checkpointer.TransactionID() == "" {
+		options = append(options, client.WithStartBlock(0))
+	}
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := network.ChaincodeEvents(sessionCtx, l.cfg.ChaincodeName, options...)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to chaincode events: %w", err)
+	}
+	log.Printf("Listening for %s events from block %d", l.cfg.ChaincodeName, checkpointer.BlockNumber())
+
+	l.mu.Lock()
+	l.network = network
+	l.status.Connected = true
+	// Checkpoint positions are copied into the status by this goroutine, the only one advancing them
+	l.status.CheckpointBlock = checkpointer.BlockNumber()
+	l.status.CheckpointTxID = checkpointer.TransactionID()
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.network = nil
+		l.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("chaincode event stream closed")
+			}
+
+			if err := applyEvent(l.db, checkpointer, event); err != nil {
+				return fmt.Errorf("block %d tx %s (%s): %w", event.BlockNumber, event.TransactionID, event.EventName, err)
+			}
+			l.update(func(s *ListenerStatus) {
+				s.LastEventAt = time.Now()
+				s.CheckpointBlock = checkpointer.BlockNumber()
+				s.CheckpointTxID = checkpointer.TransactionID()
+			})
+		}
+	}
+}
+
+// openCheckpointer returns the configured checkpoint store and a function releasing it
+func (l *EventListener) openCheckpointer() (EventCheckpointer, func(), error) {
+	if l.cfg.CheckpointFile == "" {
+		checkpointer, err := NewDBCheckpointer(l.db, ListenerCheckpointName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return checkpointer, func() {}, nil
+	}
+
+	checkpointer, err := client.NewFileCheckpointer(l.cfg.CheckpointFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	file := &fileCheckpointer{checkpointer}
+	return file, func() { checkpointer.Close() }, nil
+}
+
+// Status reports the listener's health, including how far it trails the chain height
+func (l *EventListener) Status(ctx context.Context) ListenerStatus {
+	l.mu.RLock()
+	status := l.status
+	network := l.network
+	l.mu.RUnlock()
+
+	if network != nil {
+		height, err := chainHeight(ctx, network)
+		if err != nil {
+			status.LastError = fmt.Sprintf("failed to query chain height: %v", err)
+		} else {
+			status.ChainHeight = height
+			// Blocks without asset events do not move the checkpoint, so this is an upper bound
+			if height > status.CheckpointBlock+1 {
+				status.Lag = height - status.CheckpointBlock - 1
 			}
-			log.Printf("Received block %d. Processing for consistency...", block.Header.Number)
-			
-			syncLedgerToDB(network, db)
 		}
 	}
+
+	return status
+}
+
+// Healthy reports whether the listener is currently subscribed
+func (s ListenerStatus) Healthy() bool {
+	return s.Running && s.Connected
+}
+
+func (l *EventListener) update(fn func(s *ListenerStatus)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fn(&l.status)
 }
 
-// syncLedgerToDB is a helper to pull all assets and update the DB
-func syncLedgerToDB(network *client.Network, db *gorm.DB) {
-	contract := network.GetContract("basic")
-	
-	result, err := contract.EvaluateTransaction("GetAllAssets")
+// chainHeight queries the peer's ledger height through the qscc system chaincode
+func chainHeight(ctx context.Context, network *client.Network) (uint64, error) {
+	result, err := network.GetContract("qscc").EvaluateWithContext(ctx, "GetChainInfo", client.WithArguments(network.Name()))
 	if err != nil {
-		log.Printf("Sync Error: Failed to evaluate GetAllAssets: %v", err)
-		return
-	}
-
-	var ledgerValues []models.LedgerValue
-	if err := json.Unmarshal(result, &ledgerValues); err != nil {
-		log.Printf("Sync Error: Failed to parse ledger values: %v", err)
-		return
-	}
-
-	// Sync to DB
-	for _, val := range ledgerValues {
-		asset := val.Asset
-		asset.Action = val.Audit.Action
-		asset.LastUpdatedBy = val.Audit.Actor
-		
-		if val.Audit.Timestamp != "" {
-			t, err := time.Parse(time.RFC3339, val.Audit.Timestamp)
-			if err == nil {
-				asset.LastUpdatedAt = t
+		return 0, err
+	}
+
+	var info common.BlockchainInfo
+	if err := proto.Unmarshal(result, &info); err != nil {
+		return 0, fmt.Errorf("failed to parse chain info: %w", err)
+	}
+	return info.GetHeight(), nil
+}
+
+// fileCheckpointer adapts client.FileCheckpointer. The file cannot join the projection
+// transaction, so applyEvent writes it only once that transaction has committed.
+type fileCheckpointer struct {
+	*client.FileCheckpointer
+}
+
+func (c *fileCheckpointer) CheckpointChaincodeEvent(_ *gorm.DB, event *client.ChaincodeEvent) error {
+	return c.FileCheckpointer.CheckpointChaincodeEvent(event)
+}
+
+// applyEvent upserts the asset carried by an event and advances the checkpoint in one DB transaction.
+// A checkpoint file is advanced after the commit, so a failed commit replays the event on restart.
+func applyEvent(db *gorm.DB, checkpointer EventCheckpointer, event *client.ChaincodeEvent) error {
+	file, isFile := checkpointer.(*fileCheckpointer)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if assetEvents[event.EventName] {
+			var val models.LedgerValue
+			if err := json.Unmarshal(event.Payload, &val); err != nil || val.Asset.ID == "" {
+				// A malformed payload can never succeed on retry; skip it rather than stall the projection
+				log.Printf("Projection Warning: skipping unreadable %s payload in tx %s", event.EventName, event.TransactionID)
+			} else {
+				asset := val.ToAsset()
+				if err := SaveAsset(tx, &asset); err != nil {
+					return fmt.Errorf("failed to save asset %s: %w", asset.ID, err)
+				}
+				log.Printf("Eventual Consistency: %s applied to asset %s (block %d)", event.EventName, asset.ID, event.BlockNumber)
 			}
 		}
+		if err := markCommitted(tx, event); err != nil {
+			return fmt.Errorf("failed to update tracked tx %s: %w", event.TransactionID, err)
+		}
 
-		if err := db.Save(&asset).Error; err != nil {
-			log.Printf("Sync Error: Failed to save asset %s: %v", asset.ID, err)
+		if isFile {
+			return nil
 		}
+		return checkpointer.CheckpointChaincodeEvent(tx, event)
+	})
+	if err != nil || !isFile {
+		return err
 	}
-	
-	log.Printf("Eventual Consistency: Synced %d assets from ledger to database.", len(ledgerValues))
+	return file.FileCheckpointer.CheckpointChaincodeEvent(event)
 }
@@ -0,0 +1,248 @@
+package fabric
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// PeerHealth is the per-peer health snapshot reported on the admin API
+type PeerHealth struct {
+	Name          string    `json:"name"`
+	MSPID         string    `json:"mspid"`
+	Endpoint      string    `json:"endpoint"`
+	Healthy       bool      `json:"healthy"`
+	State         string    `json:"state"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorAt   time.Time `json:"last_error_at,omitempty"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+	Failovers     int       `json:"failovers"`
+}
+
+// PeerNetwork holds one gRPC connection per peer of a connection profile. Identities are served
+// by their own organization's peers; a unary call failing with Unavailable marks the peer down
+// and is retried on the next healthy peer (own organization first, then the others).
+type PeerNetwork struct {
+	profile *ConnectionProfile
+	peers   map[string]*peerConn
+}
+
+type peerConn struct {
+	name  string
+	mspid string
+	conn  *grpc.ClientConn
+
+	mu     sync.RWMutex
+	health PeerHealth
+}
+
+// failoverKey marks calls already being retried on an alternate peer, so they don't fail over again
+type failoverKey struct{}
+
+// DialPeers creates a (lazily connecting) TLS connection to every peer in the profile
+func DialPeers(profile *ConnectionProfile) (*PeerNetwork, error) {
+	network := &PeerNetwork{profile: profile, peers: map[string]*peerConn{}}
+
+	for name, peer := range profile.Peers {
+		tlsCert, err := peer.tlsCACertPEM()
+		if err != nil {
+			network.Close()
+			return nil, fmt.Errorf("failed to read TLS cert of %s: %w", name, err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(tlsCert) {
+			network.Close()
+			return nil, fmt.Errorf("failed to append TLS cert of %s", name)
+		}
+
+		pc := &peerConn{
+			name:  name,
+			mspid: profile.orgOf(name),
+			health: PeerHealth{
+				Name:     name,
+				MSPID:    profile.orgOf(name),
+				Endpoint: peer.endpoint(),
+				Healthy:  true,
+				State:    connectivity.Idle.String(),
+			},
+		}
+		transportCreds := credentials.NewClientTLSFromCert(certPool, peer.GRPCOptions["ssl-target-name-override"])
+		conn, err := grpc.Dial(peer.endpoint(),
+			grpc.WithTransportCredentials(transportCreds),
+			grpc.WithUnaryInterceptor(network.failover(pc)),
+			grpc.WithStreamInterceptor(network.observeStream(pc)),
+		)
+		if err != nil {
+			network.Close()
+			return nil, fmt.Errorf("failed to create gRPC connection to %s: %w", name, err)
+		}
+		pc.conn = conn
+		network.peers[name] = pc
+	}
+
+	return network, nil
+}
+
+// Conn returns the connection to the preferred healthy peer for an MSP ID. When every
+// candidate is down it still returns the first one, so callers get a meaningful error.
+func (n *PeerNetwork) Conn(mspid string) *grpc.ClientConn {
+	candidates := n.candidates(mspid, "")
+	for _, pc := range candidates {
+		if pc.healthy() {
+			return pc.conn
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0].conn
+	}
+	// Unknown organization: any peer will do
+	if peers := n.sortedPeers(); len(peers) > 0 {
+		return peers[0].conn
+	}
+	return nil
+}
+
+// Health returns the health of every peer, sorted by name
+func (n *PeerNetwork) Health() []PeerHealth {
+	var health []PeerHealth
+	for _, pc := range n.sortedPeers() {
+		pc.mu.RLock()
+		health = append(health, pc.health)
+		pc.mu.RUnlock()
+	}
+	return health
+}
+
+// Monitor refreshes peer health from the connection states every interval until ctx is cancelled.
+// Idle connections are asked to connect so that a recovered peer is noticed without traffic.
+func (n *PeerNetwork) Monitor(ctx context.Context, interval time.Duration) {
+	for {
+		for _, pc := range n.sortedPeers() {
+			state := pc.conn.GetState()
+			if state == connectivity.Idle {
+				pc.conn.Connect()
+			}
+
+			pc.mu.Lock()
+			pc.health.State = state.String()
+			pc.health.LastCheckedAt = time.Now()
+			switch state {
+			case connectivity.Ready:
+				pc.health.Healthy = true
+			case connectivity.TransientFailure, connectivity.Shutdown:
+				pc.health.Healthy = false
+			}
+			pc.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Close closes every peer connection
+func (n *PeerNetwork) Close() {
+	for _, pc := range n.peers {
+		if pc.conn != nil {
+			pc.conn.Close()
+		}
+	}
+}
+
+// failover retries unary calls that fail with Unavailable on the alternates of the peer's organization
+func (n *PeerNetwork) failover(pc *peerConn) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unavailable {
+			pc.record(nil)
+			return err
+		}
+		pc.record(err)
+		if ctx.Value(failoverKey{}) != nil {
+			return err
+		}
+
+		retryCtx := context.WithValue(ctx, failoverKey{}, true)
+		for _, alternate := range n.candidates(pc.mspid, pc.name) {
+			if !alternate.healthy() {
+				continue
+			}
+			log.Printf("Peer %s unavailable, retrying %s on %s", pc.name, method, alternate.name)
+			pc.mu.Lock()
+			pc.health.Failovers++
+			pc.mu.Unlock()
+
+			if retryErr := alternate.conn.Invoke(retryCtx, method, req, reply, opts...); status.Code(retryErr) != codes.Unavailable {
+				return retryErr
+			}
+		}
+		return err
+	}
+}
+
+// observeStream records streams that cannot be opened; streaming callers such as the event
+// listener reconnect and pick the next healthy peer through Conn
+func (n *PeerNetwork) observeStream(pc *peerConn) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if status.Code(err) == codes.Unavailable {
+			pc.record(err)
+		}
+		return stream, err
+	}
+}
+
+// candidates returns the peers serving an MSP ID in order of preference, excluding one peer
+func (n *PeerNetwork) candidates(mspid string, exclude string) []*peerConn {
+	var peers []*peerConn
+	seen := map[string]bool{exclude: true}
+	for _, name := range n.profile.PeersFor(mspid) {
+		if pc, ok := n.peers[name]; ok && !seen[name] {
+			seen[name] = true
+			peers = append(peers, pc)
+		}
+	}
+	return peers
+}
+
+func (n *PeerNetwork) sortedPeers() []*peerConn {
+	var peers []*peerConn
+	for _, pc := range n.peers {
+		peers = append(peers, pc)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].name < peers[j].name })
+	return peers
+}
+
+func (pc *peerConn) healthy() bool {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	return pc.health.Healthy
+}
+
+// record updates the peer's health after a call; err is nil for a call the peer answered
+func (pc *peerConn) record(err error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.health.LastCheckedAt = time.Now()
+	if err == nil {
+		pc.health.Healthy = true
+		return
+	}
+	pc.health.Healthy = false
+	pc.health.LastError = err.Error()
+	pc.health.LastErrorAt = time.Now()
+}
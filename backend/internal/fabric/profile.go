@@ -0,0 +1,154 @@
+package fabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConnectionProfile is the subset of a Fabric common connection profile the backend uses:
+// the organizations of the network and the peers each of them runs
+type ConnectionProfile struct {
+	Name          string                         `yaml:"name" json:"name"`
+	Organizations map[string]ProfileOrganization `yaml:"organizations" json:"organizations"`
+	Peers         map[string]ProfilePeer         `yaml:"peers" json:"peers"`
+}
+
+// ProfileOrganization lists an organization's peers in order of preference
+type ProfileOrganization struct {
+	MSPID string   `yaml:"mspid" json:"mspid"`
+	Peers []string `yaml:"peers" json:"peers"`
+}
+
+// ProfilePeer is a peer endpoint with the TLS root used to verify it
+type ProfilePeer struct {
+	URL         string            `yaml:"url" json:"url"`
+	TLSCACerts  ProfileTLSCerts   `yaml:"tlsCACerts" json:"tlsCACerts"`
+	GRPCOptions map[string]string `yaml:"grpcOptions" json:"grpcOptions"`
+}
+
+// ProfileTLSCerts holds a PEM either inline or as a path relative to the profile file
+type ProfileTLSCerts struct {
+	Path string `yaml:"path" json:"path"`
+	PEM  string `yaml:"pem" json:"pem"`
+}
+
+// LoadConnectionProfile reads a YAML or JSON (by file extension) connection profile
+func LoadConnectionProfile(path string) (*ConnectionProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection profile: %w", err)
+	}
+
+	var profile ConnectionProfile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &profile)
+	} else {
+		err = yaml.Unmarshal(data, &profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection profile %s: %w", path, err)
+	}
+
+	// TLS paths are relative to the profile, not to the working directory
+	baseDir := filepath.Dir(path)
+	for name, peer := range profile.Peers {
+		if peer.TLSCACerts.Path != "" && !filepath.IsAbs(peer.TLSCACerts.Path) {
+			peer.TLSCACerts.Path = filepath.Join(baseDir, peer.TLSCACerts.Path)
+			profile.Peers[name] = peer
+		}
+	}
+
+	if err := profile.validate(); err != nil {
+		return nil, fmt.Errorf("invalid connection profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
+
+func (p *ConnectionProfile) validate() error {
+	if len(p.Organizations) == 0 {
+		return fmt.Errorf("no organizations defined")
+	}
+	for orgName, org := range p.Organizations {
+		if org.MSPID == "" {
+			return fmt.Errorf("organization %s has no mspid", orgName)
+		}
+		if len(org.Peers) == 0 {
+			return fmt.Errorf("organization %s has no peers", orgName)
+		}
+		for _, peerName := range org.Peers {
+			peer, ok := p.Peers[peerName]
+			if !ok {
+				return fmt.Errorf("organization %s references unknown peer %s", orgName, peerName)
+			}
+			if peer.URL == "" {
+				return fmt.Errorf("peer %s has no url", peerName)
+			}
+			if peer.TLSCACerts.Path == "" && peer.TLSCACerts.PEM == "" {
+				return fmt.Errorf("peer %s has no tlsCACerts", peerName)
+			}
+		}
+	}
+	return nil
+}
+
+// MSPIDs returns the MSP IDs of all organizations, ordered by organization name
+func (p *ConnectionProfile) MSPIDs() []string {
+	var mspids []string
+	for _, name := range p.orgNames() {
+		mspids = append(mspids, p.Organizations[name].MSPID)
+	}
+	return mspids
+}
+
+// PeersFor returns the peers to try for an MSP ID, in profile order. Only the organization's own
+// peers are returned: another org's peer would endorse and answer identity-scoped calls for it.
+func (p *ConnectionProfile) PeersFor(mspid string) []string {
+	var peers []string
+	for _, name := range p.orgNames() {
+		if org := p.Organizations[name]; org.MSPID == mspid {
+			peers = append(peers, org.Peers...)
+		}
+	}
+	return peers
+}
+
+func (p *ConnectionProfile) orgNames() []string {
+	var names []string
+	for name := range p.Organizations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// orgOf returns the MSP ID owning a peer
+func (p *ConnectionProfile) orgOf(peerName string) string {
+	for _, org := range p.Organizations {
+		for _, name := range org.Peers {
+			if name == peerName {
+				return org.MSPID
+			}
+		}
+	}
+	return ""
+}
+
+// endpoint strips the grpc(s):// scheme from a peer URL
+func (peer ProfilePeer) endpoint() string {
+	url := strings.TrimPrefix(peer.URL, "grpcs://")
+	return strings.TrimPrefix(url, "grpc://")
+}
+
+// tlsCACertPEM returns the peer's TLS root certificate
+func (peer ProfilePeer) tlsCACertPEM() ([]byte, error) {
+	if peer.TLSCACerts.PEM != "" {
+		return []byte(peer.TLSCACerts.PEM), nil
+	}
+	return os.ReadFile(peer.TLSCACerts.Path)
+}
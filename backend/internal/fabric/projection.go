@@ -0,0 +1,40 @@
+package fabric
+
+import (
+	"backend/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveAsset upserts an asset and replaces its attachment rows with asset.Attachments, in order
+func SaveAsset(db *gorm.DB, asset *models.Asset) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(asset).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("asset_id = ?", asset.ID).Delete(&models.AssetAttachment{}).Error; err != nil {
+			return fmt.Errorf("failed to clear attachments: %w", err)
+		}
+		if len(asset.Attachments) == 0 {
+			return nil
+		}
+		for i := range asset.Attachments {
+			asset.Attachments[i].ID = 0
+			asset.Attachments[i].AssetID = asset.ID
+			asset.Attachments[i].Position = i
+		}
+		if err := tx.Create(&asset.Attachments).Error; err != nil {
+			return fmt.Errorf("failed to save attachments: %w", err)
+		}
+		return nil
+	})
+}
+
+// OrderedAttachments preloads an asset's attachments in ledger order
+func OrderedAttachments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Attachments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	})
+}
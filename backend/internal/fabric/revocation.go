@@ -0,0 +1,406 @@
+package fabric
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/orderer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrCRLNotPublished marks a revocation that succeeded at the CA but whose CRL could not be added to the channel
+var ErrCRLNotPublished = errors.New("channel CRL was not updated")
+
+// OrdererConfig holds the orderer endpoint that channel config updates are broadcast to
+type OrdererConfig struct {
+	Endpoint     string
+	TlsCertPath  string
+	HostOverride string
+}
+
+// Revoker revokes CA identities and publishes the resulting CRL into the org's channel MSP,
+// so that revoked certificates are also rejected by peers and orderers
+type Revoker struct {
+	Peers       *PeerNetwork // Used to read the channel config through qscc
+	ChannelName string
+	Orderer     OrdererConfig
+}
+
+// RevokeUser revokes every certificate of username at the CA, purges the wallet entry and
+// pushes the new CRL to the channel. The CA revocation is permanent: the identity can no longer enroll.
+func (r *Revoker) RevokeUser(cfg CAConfig, username string, reason string) error {
+	resp, err := NewCAClient(cfg).Revoke(RevocationRequest{Name: username, Reason: reason, GenCRL: true})
+	if err != nil {
+		return fmt.Errorf("failed to revoke %s at CA: %w", username, err)
+	}
+	log.Printf("Revoked %d certificate(s) of %s (%s)", len(resp.RevokedCerts), username, cfg.MSPID)
+
+	if err := RemoveIdentity(username, cfg.MSPID, cfg.Wallet); err != nil {
+		log.Printf("Warning: failed to purge %s (%s) from wallet: %v", username, cfg.MSPID, err)
+	}
+
+	if err := r.PublishCRL(cfg, resp.CRL); err != nil {
+		return fmt.Errorf("%s was revoked at the CA but the %w: %v", username, ErrCRLNotPublished, err)
+	}
+	return nil
+}
+
+// SyncCRL fetches the CA's current CRL and publishes it to the channel (used to retry a failed publish)
+func (r *Revoker) SyncCRL(cfg CAConfig) error {
+	crl, err := NewCAClient(cfg).GenCRL()
+	if err != nil {
+		return err
+	}
+	return r.PublishCRL(cfg, crl)
+}
+
+// PublishCRL replaces the CRL from the same issuer in the org's MSP revocation list with crlPEM
+// and submits the channel config update, signed by the org admin from cfg.AdminPath
+func (r *Revoker) PublishCRL(cfg CAConfig, crlPEM []byte) error {
+	if len(crlPEM) == 0 {
+		return fmt.Errorf("CA returned an empty CRL")
+	}
+
+	adminCert, adminSign, err := loadMSPSigner(cfg.AdminPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s admin: %w", cfg.MSPID, err)
+	}
+	adminID, err := identity.NewX509Identity(cfg.MSPID, adminCert)
+	if err != nil {
+		return err
+	}
+
+	config, err := r.channelConfig(adminID, adminSign)
+	if err != nil {
+		return err
+	}
+
+	application := config.GetChannelGroup().GetGroups()["Application"]
+	orgGroup := application.GetGroups()[cfg.MSPID]
+	mspValue := orgGroup.GetValues()["MSP"]
+	if mspValue == nil {
+		return fmt.Errorf("organization %s not found in channel %s", cfg.MSPID, r.ChannelName)
+	}
+
+	var mspConfig msp.MSPConfig
+	if err := proto.Unmarshal(mspValue.GetValue(), &mspConfig); err != nil {
+		return fmt.Errorf("failed to parse MSP config: %w", err)
+	}
+	var fabricMSPConfig msp.FabricMSPConfig
+	if err := proto.Unmarshal(mspConfig.GetConfig(), &fabricMSPConfig); err != nil {
+		return fmt.Errorf("failed to parse Fabric MSP config: %w", err)
+	}
+
+	revocationList, changed, err := mergeCRL(fabricMSPConfig.GetRevocationList(), crlPEM)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	fabricMSPConfig.RevocationList = revocationList
+
+	if mspConfig.Config, err = proto.Marshal(&fabricMSPConfig); err != nil {
+		return err
+	}
+	newMSPValue, err := proto.Marshal(&mspConfig)
+	if err != nil {
+		return err
+	}
+
+	// Minimal read/write set: read the group versions, write the MSP value at version+1
+	readSet := &common.ConfigGroup{
+		Version: config.GetChannelGroup().GetVersion(),
+		Groups: map[string]*common.ConfigGroup{
+			"Application": {
+				Version: application.GetVersion(),
+				Groups: map[string]*common.ConfigGroup{
+					cfg.MSPID: {Version: orgGroup.GetVersion()},
+				},
+			},
+		},
+	}
+	writeSet := proto.Clone(readSet).(*common.ConfigGroup)
+	writeSet.Groups["Application"].Groups[cfg.MSPID].Values = map[string]*common.ConfigValue{
+		"MSP": {
+			Version:   mspValue.GetVersion() + 1,
+			Value:     newMSPValue,
+			ModPolicy: mspValue.GetModPolicy(),
+		},
+	}
+
+	update, err := proto.Marshal(&common.ConfigUpdate{ChannelId: r.ChannelName, ReadSet: readSet, WriteSet: writeSet})
+	if err != nil {
+		return err
+	}
+
+	envelope, err := r.configUpdateEnvelope(adminID, adminSign, update)
+	if err != nil {
+		return err
+	}
+	return r.broadcast(envelope)
+}
+
+// channelConfig reads the latest config block through qscc and returns its channel config
+func (r *Revoker) channelConfig(id *identity.X509Identity, sign identity.Sign) (*common.Config, error) {
+	gw, err := CreateGateway(r.Peers.Conn(id.MspID()), id, sign)
+	if err != nil {
+		return nil, err
+	}
+	defer gw.Close()
+
+	network := gw.GetNetwork(r.ChannelName)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	height, err := chainHeight(ctx, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain height: %w", err)
+	}
+	latest, err := blockByNumber(ctx, network, height-1)
+	if err != nil {
+		return nil, err
+	}
+	lastConfig, err := lastConfigIndex(latest)
+	if err != nil {
+		return nil, err
+	}
+	configBlock, err := blockByNumber(ctx, network, lastConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configBlock.GetData().GetData()) == 0 {
+		return nil, fmt.Errorf("config block %d is empty", lastConfig)
+	}
+	var envelope common.Envelope
+	if err := proto.Unmarshal(configBlock.GetData().GetData()[0], &envelope); err != nil {
+		return nil, err
+	}
+	var payload common.Payload
+	if err := proto.Unmarshal(envelope.GetPayload(), &payload); err != nil {
+		return nil, err
+	}
+	var configEnvelope common.ConfigEnvelope
+	if err := proto.Unmarshal(payload.GetData(), &configEnvelope); err != nil {
+		return nil, fmt.Errorf("failed to parse config envelope: %w", err)
+	}
+	return configEnvelope.GetConfig(), nil
+}
+
+// configUpdateEnvelope signs a config update as the org admin and wraps it in a CONFIG_UPDATE envelope
+func (r *Revoker) configUpdateEnvelope(id *identity.X509Identity, sign identity.Sign, update []byte) (*common.Envelope, error) {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: id.MspID(), IdBytes: id.Credentials()})
+	if err != nil {
+		return nil, err
+	}
+
+	signatureHeader, nonce, err := newSignatureHeader(creator)
+	if err != nil {
+		return nil, err
+	}
+	configSignature, err := signDigest(sign, signatureHeader, update)
+	if err != nil {
+		return nil, err
+	}
+	updateEnvelope, err := proto.Marshal(&common.ConfigUpdateEnvelope{
+		ConfigUpdate: update,
+		Signatures:   []*common.ConfigSignature{{SignatureHeader: signatureHeader, Signature: configSignature}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txID := sha256.Sum256(append(append([]byte{}, nonce...), creator...))
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{
+		Type:      int32(common.HeaderType_CONFIG_UPDATE),
+		ChannelId: r.ChannelName,
+		TxId:      hex.EncodeToString(txID[:]),
+		Timestamp: timestamppb.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&common.Payload{
+		Header: &common.Header{ChannelHeader: channelHeader, SignatureHeader: signatureHeader},
+		Data:   updateEnvelope,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payloadSignature, err := signDigest(sign, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.Envelope{Payload: payload, Signature: payloadSignature}, nil
+}
+
+// broadcast sends an envelope to the orderer and waits for its acknowledgement
+func (r *Revoker) broadcast(envelope *common.Envelope) error {
+	tlsCert, err := os.ReadFile(r.Orderer.TlsCertPath)
+	if err != nil {
+		return fmt.Errorf("failed to read orderer TLS cert: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(tlsCert) {
+		return fmt.Errorf("failed to append orderer TLS cert")
+	}
+
+	conn, err := grpc.Dial(r.Orderer.Endpoint, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, r.Orderer.HostOverride)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to orderer: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stream, err := orderer.NewAtomicBroadcastClient(conn).Broadcast(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open broadcast stream: %w", err)
+	}
+	if err := stream.Send(envelope); err != nil {
+		return fmt.Errorf("failed to send config update: %w", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to receive broadcast response: %w", err)
+	}
+	stream.CloseSend()
+
+	if resp.GetStatus() != common.Status_SUCCESS {
+		return fmt.Errorf("orderer rejected config update: %s %s", resp.GetStatus(), resp.GetInfo())
+	}
+	return nil
+}
+
+// mergeCRL replaces any CRL from the same issuer as crlPEM and reports whether the list changed
+func mergeCRL(existing [][]byte, crlPEM []byte) ([][]byte, bool, error) {
+	newCRL, err := parseCRL(crlPEM)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid CRL from CA: %w", err)
+	}
+
+	merged := [][]byte{}
+	for _, entry := range existing {
+		if bytes.Equal(entry, crlPEM) {
+			return existing, false, nil
+		}
+		crl, err := parseCRL(entry)
+		if err == nil && bytes.Equal(crl.RawIssuer, newCRL.RawIssuer) {
+			continue
+		}
+		merged = append(merged, entry)
+	}
+	return append(merged, crlPEM), true, nil
+}
+
+func parseCRL(crlPEM []byte) (*x509.RevocationList, error) {
+	block, _ := pem.Decode(crlPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid CRL PEM")
+	}
+	return x509.ParseRevocationList(block.Bytes)
+}
+
+// lastConfigIndex reads the number of the latest config block from a block's metadata
+func lastConfigIndex(block *common.Block) (uint64, error) {
+	metadata := block.GetMetadata().GetMetadata()
+	if len(metadata) > int(common.BlockMetadataIndex_SIGNATURES) {
+		var signatures common.Metadata
+		if err := proto.Unmarshal(metadata[common.BlockMetadataIndex_SIGNATURES], &signatures); err == nil {
+			var ordererMetadata common.OrdererBlockMetadata
+			if err := proto.Unmarshal(signatures.GetValue(), &ordererMetadata); err == nil && ordererMetadata.GetLastConfig() != nil {
+				return ordererMetadata.GetLastConfig().GetIndex(), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("block %d has no last config metadata", block.GetHeader().GetNumber())
+}
+
+func blockByNumber(ctx context.Context, network *client.Network, number uint64) (*common.Block, error) {
+	result, err := network.GetContract("qscc").EvaluateWithContext(ctx, "GetBlockByNumber",
+		client.WithArguments(network.Name(), strconv.FormatUint(number, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block %d: %w", number, err)
+	}
+
+	var block common.Block
+	if err := proto.Unmarshal(result, &block); err != nil {
+		return nil, fmt.Errorf("failed to parse block %d: %w", number, err)
+	}
+	return &block, nil
+}
+
+func newSignatureHeader(creator []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	header, err := proto.Marshal(&common.SignatureHeader{Creator: creator, Nonce: nonce})
+	return header, nonce, err
+}
+
+// signDigest signs the SHA-256 of the concatenated messages
+func signDigest(sign identity.Sign, messages ...[]byte) ([]byte, error) {
+	digest := sha256.New()
+	for _, message := range messages {
+		digest.Write(message)
+	}
+	return sign(digest.Sum(nil))
+}
+
+// loadMSPSigner loads the certificate and signer of a cryptogen MSP directory (signcerts + keystore)
+func loadMSPSigner(mspDir string) (*x509.Certificate, identity.Sign, error) {
+	certPath, err := firstFile(filepath.Join(mspDir, "signcerts"))
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPath, err := firstFile(filepath.Join(mspDir, "keystore"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := loadCertificate(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	sign, err := loadPrivateKey(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, sign, nil
+}
+
+func firstFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			return filepath.Join(dir, entry.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("no files in %s", dir)
+}
@@ -0,0 +1,87 @@
+package fabric
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+)
+
+// RoleAttribute is the CA attribute carrying a user's role; the chaincode checks it with cid.AssertAttributeValue
+const RoleAttribute = "role"
+
+// Roles issued as certificate attributes
+const (
+	RoleAdmin   = "admin"
+	RoleAuditor = "auditor"
+	RoleUser    = "user"
+)
+
+// attrsOID is the X.509 extension in which Fabric CA embeds ecert attributes as JSON
+var attrsOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// ValidRole reports whether role is one the chaincode understands
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleAuditor || role == RoleUser
+}
+
+// roleAttribute is the CA attribute that puts the role into enrollment certificates
+func roleAttribute(role string) CAAttribute {
+	return CAAttribute{Name: RoleAttribute, Value: role, ECert: true}
+}
+
+// CertificateAttributes returns the Fabric CA attributes embedded in a PEM certificate
+func CertificateAttributes(certPEM []byte) (map[string]string, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(attrsOID) {
+			continue
+		}
+		var attrs struct {
+			Attrs map[string]string `json:"attrs"`
+		}
+		if err := json.Unmarshal(ext.Value, &attrs); err != nil {
+			return nil, fmt.Errorf("failed to decode certificate attributes: %v", err)
+		}
+		return attrs.Attrs, nil
+	}
+	return map[string]string{}, nil
+}
+
+// GetIdentityRole returns the role attribute of a wallet identity's certificate ("" when absent)
+func GetIdentityRole(username string, mspid string, wallet Wallet) (string, error) {
+	stored, err := wallet.Get(mspid, username)
+	if err != nil {
+		return "", err
+	}
+	attrs, err := CertificateAttributes(stored.Certificate)
+	if err != nil {
+		return "", err
+	}
+	return attrs[RoleAttribute], nil
+}
+
+// SetUserRole changes the role attribute of a registered CA identity. The new role is
+// only present in certificates issued after the change, i.e. from the user's next enrollment.
+func SetUserRole(cfg CAConfig, username, role string) error {
+	if !ValidRole(role) {
+		return fmt.Errorf("invalid role %q", role)
+	}
+
+	err := NewCAClient(cfg).ModifyIdentity(username, ModifyIdentityRequest{
+		Attributes: []CAAttribute{roleAttribute(role)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set role for %s: %v", username, err)
+	}
+	return nil
+}
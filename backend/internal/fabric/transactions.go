@@ -0,0 +1,204 @@
+package fabric
+
+import (
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"gorm.io/gorm"
+)
+
+// Constants for tracked transaction status
+const (
+	TxEndorsed  = "ENDORSED"
+	TxSubmitted = "SUBMITTED"
+	TxCommitted = "COMMITTED"
+	TxInvalid   = "INVALID"
+	TxFailed    = "FAILED"
+)
+
+// TransactionTracker submits transactions without waiting for commit and records their
+// progress in the transactions table
+type TransactionTracker struct {
+	db *gorm.DB
+	// commitTimeout bounds the wait for a commit status; a transaction still SUBMITTED after it
+	// is completed by the event listener if it did commit
+	commitTimeout time.Duration
+}
+
+// NewTransactionTracker creates a tracker; commitTimeout defaults to five minutes
+func NewTransactionTracker(db *gorm.DB, commitTimeout time.Duration) *TransactionTracker {
+	if commitTimeout <= 0 {
+		commitTimeout = 5 * time.Minute
+	}
+	return &TransactionTracker{db: db, commitTimeout: commitTimeout}
+}
+
+// SubmitAsync endorses and submits record.Function on the lease's contract and returns the
+// transaction ID once the orderer accepted it. Endorsement failures are returned without a
+// record. An empty record.AssetID is filled in from the endorsed result, which is how
+// CreateAsset reports a generated ID. The commit status is awaited in the background; onCommit
+// (optional) runs once the transaction committed successfully.
+func (t *TransactionTracker) SubmitAsync(lease *GatewayLease, record *models.Transaction, onCommit func(), opts ...client.ProposalOption) (string, error) {
+	proposal, err := lease.Contract().NewProposal(record.Function, opts...)
+	if err != nil {
+		return "", err
+	}
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		return "", err
+	}
+
+	record.TxID = transaction.TransactionID()
+	record.Status = TxEndorsed
+	if record.AssetID == "" {
+		record.AssetID = string(transaction.Result())
+	}
+	if err := t.db.Create(record).Error; err != nil {
+		return "", fmt.Errorf("failed to record transaction %s: %w", record.TxID, err)
+	}
+
+	commit, err := transaction.Submit()
+	if err != nil {
+		t.update(record.TxID, map[string]interface{}{"status": TxFailed, "error": err.Error()})
+		return record.TxID, err
+	}
+	t.update(record.TxID, map[string]interface{}{"status": TxSubmitted})
+
+	// The request's lease is closed when the handler returns; keep the gateway open until the status arrives
+	go t.await(lease.Retain(), commit, onCommit)
+	return record.TxID, nil
+}
+
+// Submit endorses and submits record.Function on the lease's contract and waits for it to commit
+// like SubmitTransaction, filling in record.TxID. When the commit status can't be obtained (the
+// wait timed out or the peer went away) the transaction may still commit: it is then recorded
+// as SUBMITTED and awaited in the background as in SubmitAsync, running onCommit if it commits,
+// and the error is returned with record.Status set to TxSubmitted.
+func (t *TransactionTracker) Submit(lease *GatewayLease, record *models.Transaction, onCommit func(), opts ...client.ProposalOption) ([]byte, error) {
+	proposal, err := lease.Contract().NewProposal(record.Function, opts...)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		return nil, err
+	}
+	record.TxID = transaction.TransactionID()
+	if record.AssetID == "" {
+		record.AssetID = string(transaction.Result())
+	}
+
+	commit, err := transaction.Submit()
+	if err != nil {
+		return nil, err
+	}
+	status, err := commit.Status()
+	if err != nil {
+		record.Status = TxSubmitted
+		record.Error = err.Error()
+		if dbErr := t.db.Create(record).Error; dbErr != nil {
+			log.Printf("Failed to record transaction %s: %v", record.TxID, dbErr)
+			record.Status = ""
+			return nil, err
+		}
+		go t.await(lease.Retain(), commit, onCommit)
+		return nil, err
+	}
+	if !status.Successful {
+		return nil, invalidTransactionError(status.TransactionID, status.Code)
+	}
+	return transaction.Result(), nil
+}
+
+// SubmitTransaction endorses and submits a transaction and waits for it to commit, like
+// client.Contract.Submit, but also returns the transaction ID with the result. An invalidated transaction is
+// returned as a *ContractError.
+func SubmitTransaction(contract *client.Contract, name string, opts ...client.ProposalOption) (string, []byte, error) {
+	proposal, err := contract.NewProposal(name, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		return "", nil, err
+	}
+	commit, err := transaction.Submit()
+	if err != nil {
+		return transaction.TransactionID(), nil, err
+	}
+	status, err := commit.Status()
+	if err != nil {
+		return transaction.TransactionID(), nil, err
+	}
+	if !status.Successful {
+		return status.TransactionID, nil, invalidTransactionError(status.TransactionID, status.Code)
+	}
+	return status.TransactionID, transaction.Result(), nil
+}
+
+// Get returns a tracked transaction
+func (t *TransactionTracker) Get(txID string) (*models.Transaction, error) {
+	var record models.Transaction
+	if err := t.db.Where("tx_id = ?", txID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (t *TransactionTracker) await(lease *GatewayLease, commit *client.Commit, onCommit func()) {
+	defer lease.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.commitTimeout)
+	defer cancel()
+
+	txID := commit.TransactionID()
+	status, err := commit.StatusWithContext(ctx)
+	if err != nil {
+		log.Printf("Commit status of tx %s unavailable: %v", txID, err)
+		t.update(txID, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if !status.Successful {
+		log.Printf("Tx %s invalidated in block %d: %s", txID, status.BlockNumber, status.Code)
+		t.update(txID, map[string]interface{}{
+			"status":          TxInvalid,
+			"validation_code": status.Code.String(),
+			"block_number":    status.BlockNumber,
+		})
+		return
+	}
+
+	t.update(txID, map[string]interface{}{
+		"status":          TxCommitted,
+		"validation_code": status.Code.String(),
+		"block_number":    status.BlockNumber,
+		"error":           "",
+	})
+	if onCommit != nil {
+		onCommit()
+	}
+}
+
+func (t *TransactionTracker) update(txID string, fields map[string]interface{}) {
+	if err := t.db.Model(&models.Transaction{}).Where("tx_id = ?", txID).Updates(fields).Error; err != nil {
+		log.Printf("Failed to update transaction %s: %v", txID, err)
+	}
+}
+
+// markCommitted completes a tracked transaction seen by the event listener, e.g. one whose
+// commit status wait timed out or was interrupted by a restart
+func markCommitted(db *gorm.DB, event *client.ChaincodeEvent) error {
+	return db.Model(&models.Transaction{}).
+		Where("tx_id = ? AND status IN ?", event.TransactionID, []string{TxEndorsed, TxSubmitted}).
+		Updates(map[string]interface{}{
+			"status":          TxCommitted,
+			"validation_code": "VALID",
+			"block_number":    event.BlockNumber,
+			"error":           "",
+		}).Error
+}
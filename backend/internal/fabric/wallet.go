@@ -3,56 +3,92 @@ package fabric
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"gorm.io/gorm"
 )
 
-// SaveIdentity saves the private key, certificate, and MSP ID to the file system
-func SaveIdentity(username string, cert []byte, key []byte, walletPath string, mspid string) error {
-	userDir := filepath.Join(walletPath, mspid, username)
-	if err := os.MkdirAll(userDir, 0700); err != nil {
-		return fmt.Errorf("failed to create wallet dir: %v", err)
-	}
+// ErrIdentityNotFound is returned by a Wallet when no credentials are stored for a user
+var ErrIdentityNotFound = errors.New("identity not found in wallet")
 
-	if err := os.WriteFile(filepath.Join(userDir, "cert.pem"), cert, 0644); err != nil {
-		return fmt.Errorf("failed to write cert: %v", err)
-	}
-	if err := os.WriteFile(filepath.Join(userDir, "key.pem"), key, 0600); err != nil {
-		return fmt.Errorf("failed to write key: %v", err)
-	}
-	
-	// Create MSP ID file
-	if err := os.WriteFile(filepath.Join(userDir, "mspid"), []byte(mspid), 0644); err != nil {
-		return fmt.Errorf("failed to write mspid: %v", err) 
-	}
+// WalletIdentity is an enrollment as stored in a wallet: PEM certificate and PEM private key
+type WalletIdentity struct {
+	MSPID       string
+	Certificate []byte
+	PrivateKey  []byte
+}
 
-	return nil
+// Wallet stores enrollment credentials per organization (MSP ID) and username
+type Wallet interface {
+	Put(mspid, username string, id *WalletIdentity) error
+	Get(mspid, username string) (*WalletIdentity, error)
+	List(mspid string) ([]string, error)
+	Remove(mspid, username string) error
 }
 
-// GetIdentity loads the credentials from the file system and returns an X509Identity
-func GetIdentity(username string, mspid string, walletPath string) (*identity.X509Identity, identity.Sign, error) {
-	userDir := filepath.Join(walletPath, mspid, username)
-	
-	certPath := filepath.Join(userDir, "cert.pem")
-	keyPath := filepath.Join(userDir, "key.pem")
-	mspidPath := filepath.Join(userDir, "mspid")
+// Wallet backends selectable through WalletConfig.Type
+const (
+	WalletTypeFile      = "file"
+	WalletTypeEncrypted = "encrypted"
+	WalletTypePostgres  = "postgres"
+)
 
-	certPEM, err := os.ReadFile(certPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read cert: %v", err)
+// WalletConfig selects and configures a wallet backend
+type WalletConfig struct {
+	Type string // file (default), encrypted or postgres
+	Path string // Root directory of the file and encrypted backends
+	// MasterKey is a base64 AES-256 key sealing private keys. Required by the encrypted and
+	// Postgres backends.
+	MasterKey string
+}
+
+// NewWallet creates the wallet backend described by cfg (db is only used by the Postgres backend)
+func NewWallet(cfg WalletConfig, db *gorm.DB) (Wallet, error) {
+	switch cfg.Type {
+	case "", WalletTypeFile:
+		return NewFileWallet(cfg.Path), nil
+	case WalletTypeEncrypted:
+		aead, err := newWalletAEAD(cfg.MasterKey)
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileWallet(cfg.Path, aead), nil
+	case WalletTypePostgres:
+		if db == nil {
+			return nil, fmt.Errorf("postgres wallet requires a database connection")
+		}
+		aead, err := newWalletAEAD(cfg.MasterKey)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresWallet(db, aead), nil
+	default:
+		return nil, fmt.Errorf("unknown wallet type %q", cfg.Type)
 	}
+}
+
+// SaveIdentity saves the private key, certificate, and MSP ID to the wallet
+func SaveIdentity(username string, cert []byte, key []byte, wallet Wallet, mspid string) error {
+	return wallet.Put(mspid, username, &WalletIdentity{MSPID: mspid, Certificate: cert, PrivateKey: key})
+}
 
-	// Check for MSP ID file if needed, but we rely on the parameter mspid now
-	if mspidBytes, err := os.ReadFile(mspidPath); err == nil {
-		// Use file value if exists for legacy support, otherwise use parameter
-		mspid = string(mspidBytes)
+// GetIdentity loads the credentials from the wallet and returns an X509Identity
+func GetIdentity(username string, mspid string, wallet Wallet) (*identity.X509Identity, identity.Sign, error) {
+	stored, err := wallet.Get(mspid, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The stored MSP ID wins for identities saved by older versions
+	if stored.MSPID != "" {
+		mspid = stored.MSPID
 	}
 
 	// Gateway Identity
-	certificate, err := identity.CertificateFromPEM(certPEM)
+	certificate, err := identity.CertificateFromPEM(stored.Certificate)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse certificate: %v", err)
 	}
@@ -62,21 +98,10 @@ func GetIdentity(username string, mspid string, walletPath string) (*identity.X5
 		return nil, nil, fmt.Errorf("failed to create X509Identity: %v", err)
 	}
 
-	// Signer
-	// We need to implement a parser for the private key to return a Sign function
-	// Re-using the logic from client.go's loadPrivateKey would be ideal, 
-	// but to avoid circular deps or code duplication, let's copy the helper here or export it.
-	// For now, I'll inline the key loading logic.
-	
-	privateKeyPEM, err := os.ReadFile(keyPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read key: %v", err)
-	}
-	
-	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	privateKey, err := identity.PrivateKeyFromPEM(stored.PrivateKey)
 	if err != nil {
 		// Fallback for SEC1/EC Private Key if standard parser fails
-		block, _ := pem.Decode(privateKeyPEM)
+		block, _ := pem.Decode(stored.PrivateKey)
 		if block != nil && block.Type == "EC PRIVATE KEY" {
 			privateKey, err = x509.ParseECPrivateKey(block.Bytes)
 			if err != nil {
@@ -96,11 +121,49 @@ func GetIdentity(username string, mspid string, walletPath string) (*identity.X5
 }
 
 // IdentityExists checks if a user is in the wallet
-func IdentityExists(username string, mspid string, walletPath string) bool {
-	if _, err := os.Stat(filepath.Join(walletPath, mspid, username, "cert.pem")); os.IsNotExist(err) {
-		return false
+func IdentityExists(username string, mspid string, wallet Wallet) bool {
+	_, err := wallet.Get(mspid, username)
+	return err == nil
+}
+
+// RemoveIdentity deletes a user's credentials from the wallet
+func RemoveIdentity(username string, mspid string, wallet Wallet) error {
+	if err := wallet.Remove(mspid, username); err != nil {
+		return fmt.Errorf("failed to remove identity: %v", err)
+	}
+	return nil
+}
+
+// MigrateWallet copies every identity of the given organizations from one wallet to another,
+// optionally removing it from the source, and returns the number of identities moved
+func MigrateWallet(from Wallet, to Wallet, mspids []string, removeSource bool) (int, error) {
+	migrated := 0
+	for _, mspid := range mspids {
+		usernames, err := from.List(mspid)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to list %s identities: %w", mspid, err)
+		}
+
+		for _, username := range usernames {
+			id, err := from.Get(mspid, username)
+			if err != nil {
+				return migrated, fmt.Errorf("failed to read %s (%s): %w", username, mspid, err)
+			}
+			if id.MSPID == "" {
+				id.MSPID = mspid
+			}
+			if err := to.Put(mspid, username, id); err != nil {
+				return migrated, fmt.Errorf("failed to write %s (%s): %w", username, mspid, err)
+			}
+			if removeSource {
+				if err := from.Remove(mspid, username); err != nil {
+					return migrated, fmt.Errorf("failed to remove %s (%s) from source: %w", username, mspid, err)
+				}
+			}
+			migrated++
+		}
 	}
-	return true
+	return migrated, nil
 }
 
 // GetMSPDirFromCryptoConfig returns a path to an enrolled user in crypto-config (For Admin)
@@ -0,0 +1,188 @@
+package fabric
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileWallet is the plaintext directory layout <path>/<MSPID>/<username>/{cert.pem,key.pem,mspid}
+type FileWallet struct {
+	path string
+}
+
+// NewFileWallet opens a filesystem wallet rooted at path
+func NewFileWallet(path string) *FileWallet {
+	return &FileWallet{path: path}
+}
+
+func (w *FileWallet) userDir(mspid, username string) string {
+	return filepath.Join(w.path, mspid, username)
+}
+
+// Put writes the identity's certificate, key and MSP ID files
+func (w *FileWallet) Put(mspid, username string, id *WalletIdentity) error {
+	return w.write(mspid, username, id, "key.pem", id.PrivateKey)
+}
+
+// write stores the certificate and MSP ID in plaintext and the key under keyFile
+func (w *FileWallet) write(mspid, username string, id *WalletIdentity, keyFile string, key []byte) error {
+	userDir := w.userDir(mspid, username)
+	if err := os.MkdirAll(userDir, 0700); err != nil {
+		return fmt.Errorf("failed to create wallet dir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(userDir, "cert.pem"), id.Certificate, 0644); err != nil {
+		return fmt.Errorf("failed to write cert: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(userDir, keyFile), key, 0600); err != nil {
+		return fmt.Errorf("failed to write key: %v", err)
+	}
+
+	// Create MSP ID file
+	if err := os.WriteFile(filepath.Join(userDir, "mspid"), []byte(id.MSPID), 0644); err != nil {
+		return fmt.Errorf("failed to write mspid: %v", err)
+	}
+
+	return nil
+}
+
+// Get reads an identity's certificate and key
+func (w *FileWallet) Get(mspid, username string) (*WalletIdentity, error) {
+	return w.read(mspid, username, "key.pem")
+}
+
+func (w *FileWallet) read(mspid, username string, keyFile string) (*WalletIdentity, error) {
+	userDir := w.userDir(mspid, username)
+
+	certPEM, err := os.ReadFile(filepath.Join(userDir, "cert.pem"))
+	if os.IsNotExist(err) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cert: %v", err)
+	}
+	key, err := os.ReadFile(filepath.Join(userDir, keyFile))
+	if os.IsNotExist(err) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %v", err)
+	}
+
+	id := &WalletIdentity{MSPID: mspid, Certificate: certPEM, PrivateKey: key}
+	if storedMSPID, err := os.ReadFile(filepath.Join(userDir, "mspid")); err == nil && len(storedMSPID) > 0 {
+		id.MSPID = string(storedMSPID)
+	}
+	return id, nil
+}
+
+// List returns the usernames with a certificate under the organization's directory
+func (w *FileWallet) List(mspid string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(w.path, mspid))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var usernames []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(w.path, mspid, entry.Name(), "cert.pem")); err == nil {
+			usernames = append(usernames, entry.Name())
+		}
+	}
+	return usernames, nil
+}
+
+// Remove deletes the identity's directory
+func (w *FileWallet) Remove(mspid, username string) error {
+	return os.RemoveAll(w.userDir(mspid, username))
+}
+
+// EncryptedFileWallet uses the FileWallet layout but stores private keys AES-GCM sealed in key.enc
+type EncryptedFileWallet struct {
+	FileWallet
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileWallet opens an encrypted filesystem wallet rooted at path
+func NewEncryptedFileWallet(path string, aead cipher.AEAD) *EncryptedFileWallet {
+	return &EncryptedFileWallet{FileWallet: FileWallet{path: path}, aead: aead}
+}
+
+// Put seals the private key and writes the identity, removing any plaintext key.pem left behind
+func (w *EncryptedFileWallet) Put(mspid, username string, id *WalletIdentity) error {
+	sealed, err := sealWalletKey(w.aead, mspid, username, id.PrivateKey)
+	if err != nil {
+		return err
+	}
+	if err := w.write(mspid, username, id, "key.enc", sealed); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(w.userDir(mspid, username), "key.pem")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove plaintext key: %v", err)
+	}
+	return nil
+}
+
+// Get reads an identity and decrypts its private key
+func (w *EncryptedFileWallet) Get(mspid, username string) (*WalletIdentity, error) {
+	id, err := w.read(mspid, username, "key.enc")
+	if err != nil {
+		return nil, err
+	}
+	if id.PrivateKey, err = openWalletKey(w.aead, mspid, username, id.PrivateKey); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// newWalletAEAD builds the AES-256-GCM cipher from a base64 master key
+func newWalletAEAD(masterKey string) (cipher.AEAD, error) {
+	if masterKey == "" {
+		return nil, fmt.Errorf("wallet master key is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("wallet master key must be base64: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("wallet master key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealWalletKey encrypts a private key as nonce||ciphertext, bound to the identity it belongs to
+func sealWalletKey(aead cipher.AEAD, mspid, username string, key []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, key, []byte(mspid+"/"+username)), nil
+}
+
+func openWalletKey(aead cipher.AEAD, mspid, username string, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted key for %s is truncated", username)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	key, err := aead.Open(nil, nonce, ciphertext, []byte(mspid+"/"+username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key for %s: %v", username, err)
+	}
+	return key, nil
+}
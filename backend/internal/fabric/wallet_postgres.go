@@ -0,0 +1,80 @@
+package fabric
+
+import (
+	"backend/internal/models"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// PostgresWallet stores identities in the wallet_entries table. With an AEAD, private keys are
+// sealed before they reach the database.
+type PostgresWallet struct {
+	db   *gorm.DB
+	aead cipher.AEAD
+}
+
+// NewPostgresWallet creates a database-backed wallet; aead may be nil to store keys in plaintext
+func NewPostgresWallet(db *gorm.DB, aead cipher.AEAD) *PostgresWallet {
+	return &PostgresWallet{db: db, aead: aead}
+}
+
+// Put inserts or replaces an identity
+func (w *PostgresWallet) Put(mspid, username string, id *WalletIdentity) error {
+	entry := models.WalletEntry{
+		MSPID:       mspid,
+		Username:    username,
+		Certificate: string(id.Certificate),
+		PrivateKey:  id.PrivateKey,
+	}
+	if w.aead != nil {
+		sealed, err := sealWalletKey(w.aead, mspid, username, id.PrivateKey)
+		if err != nil {
+			return err
+		}
+		entry.PrivateKey = sealed
+		entry.Encrypted = true
+	}
+
+	if err := w.db.Save(&entry).Error; err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+	return nil
+}
+
+// Get loads an identity, decrypting its key if it was stored sealed
+func (w *PostgresWallet) Get(mspid, username string) (*WalletIdentity, error) {
+	var entry models.WalletEntry
+	err := w.db.Where("msp_id = ? AND username = ?", mspid, username).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load identity: %w", err)
+	}
+
+	key := entry.PrivateKey
+	if entry.Encrypted {
+		if w.aead == nil {
+			return nil, fmt.Errorf("key for %s is encrypted but no wallet master key is configured", username)
+		}
+		if key, err = openWalletKey(w.aead, mspid, username, key); err != nil {
+			return nil, err
+		}
+	}
+	return &WalletIdentity{MSPID: entry.MSPID, Certificate: []byte(entry.Certificate), PrivateKey: key}, nil
+}
+
+// List returns the usernames stored for an organization
+func (w *PostgresWallet) List(mspid string) ([]string, error) {
+	var usernames []string
+	err := w.db.Model(&models.WalletEntry{}).Where("msp_id = ?", mspid).Order("username").Pluck("username", &usernames).Error
+	return usernames, err
+}
+
+// Remove deletes an identity
+func (w *PostgresWallet) Remove(mspid, username string) error {
+	return w.db.Where("msp_id = ? AND username = ?", mspid, username).Delete(&models.WalletEntry{}).Error
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -16,7 +17,12 @@ type User struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// AssetAttachment is one file attached to an asset, stored in asset_attachments in the asset's
+// ledger order
 type AssetAttachment struct {
+	ID          uint   `gorm:"primaryKey" json:"-"`
+	AssetID     string `gorm:"index;not null" json:"-"`
+	Position    int    `json:"-"`
 	FileName    string `json:"file_name"`
 	FileSize    int64  `json:"file_size"`
 	FileHash    string `json:"file_hash"`
@@ -31,17 +37,59 @@ type Asset struct {
 	Description     string    `json:"description"`
 	OwnerID         string    `json:"ownerId"`
 	ProposedOwnerID string    `json:"proposedOwnerId"`
+	TransferExpiresAt string  `json:"transferExpiresAt"`
 	ImageURL        string    `json:"imageUrl"`
 	ImageHash       string    `json:"imageHash"`
 	Status          string          `json:"status"`
 	View            string          `json:"view"`
-	Attachment      AssetAttachment `gorm:"embedded;embeddedPrefix:attach_" json:"attachment"`
+	Attachments     []AssetAttachment `gorm:"foreignKey:AssetID;constraint:OnDelete:CASCADE" json:"attachments"`
+	PrivateDataHash string          `json:"privateDataHash"` // Set when details live in the owner's private collection
 	// Metadata (Flattened for DB)
 	LastUpdatedBy string    `json:"lastUpdatedBy"`
 	LastUpdatedAt time.Time `json:"lastUpdatedAt"`
 	Action        string    `json:"action"`
 }
 
+// UnmarshalJSON also reads ledger values written before attachments became a list, such as
+// events replayed from old blocks, whose single attachment becomes the first entry
+func (a *Asset) UnmarshalJSON(data []byte) error {
+	type plain Asset
+	var decoded struct {
+		plain
+		Attachment *AssetAttachment `json:"attachment"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*a = Asset(decoded.plain)
+	if len(a.Attachments) == 0 && decoded.Attachment != nil && decoded.Attachment.FileName != "" {
+		a.Attachments = []AssetAttachment{*decoded.Attachment}
+	}
+	return nil
+}
+
+// AssetPrivateDetails are the fields of a PRIVATE asset held in the owner's implicit org collection
+type AssetPrivateDetails struct {
+	ID          string            `json:"ID"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	ImageURL    string            `json:"imageUrl"`
+	ImageHash   string            `json:"imageHash"`
+	Attachments []AssetAttachment `json:"attachments,omitempty"`
+	Salt        string            `json:"salt,omitempty"`
+}
+
+// AssetMetadataUpdate mirrors the chaincode's MetadataUpdate: nil fields are left unchanged and
+// attachments replace the whole list
+type AssetMetadataUpdate struct {
+	Name        *string            `json:"name,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	ImageURL    *string            `json:"imageUrl,omitempty"`
+	ImageHash   *string            `json:"imageHash,omitempty"`
+	Attachments *[]AssetAttachment `json:"attachments,omitempty"`
+	Salt        string             `json:"salt,omitempty"`
+}
+
 type AuditMetadata struct {
 	Action    string `json:"action"`
 	Actor     string `json:"actor"`
@@ -53,6 +101,27 @@ type LedgerValue struct {
 	Audit AuditMetadata `json:"audit"`
 }
 
+// ToAsset flattens the audit metadata into the asset for frontend/DB use
+func (v LedgerValue) ToAsset() Asset {
+	asset := v.Asset
+	asset.Action = v.Audit.Action
+	asset.LastUpdatedBy = v.Audit.Actor
+	if v.Audit.Timestamp != "" {
+		t, err := time.Parse(time.RFC3339, v.Audit.Timestamp)
+		if err == nil {
+			asset.LastUpdatedAt = t
+		}
+	}
+	return asset
+}
+
+// PaginatedQueryResult mirrors the chaincode's paginated query response
+type PaginatedQueryResult struct {
+	Records             []LedgerValue `json:"records"`
+	FetchedRecordsCount int32         `json:"fetchedRecordsCount"`
+	Bookmark            string        `json:"bookmark"`
+}
+
 type HistoryRecord struct {
 	TxId       string    `json:"txId"`
 	Timestamp  time.Time `json:"timestamp"`
@@ -62,6 +131,75 @@ type HistoryRecord struct {
 	IsDelete   bool      `json:"isDelete"`
 }
 
+// EventCheckpoint stores how far an event listener has projected the ledger
+type EventCheckpoint struct {
+	Name          string    `gorm:"primaryKey" json:"name"`
+	BlockNumber   uint64    `json:"block_number"`
+	TransactionID string    `json:"transaction_id"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// WalletEntry is an enrollment held by the Postgres wallet backend
+type WalletEntry struct {
+	MSPID       string    `gorm:"column:msp_id;primaryKey" json:"msp_id"`
+	Username    string    `gorm:"primaryKey" json:"username"`
+	Certificate string    `gorm:"type:text;not null" json:"-"`
+	PrivateKey  []byte    `gorm:"not null" json:"-"` // AES-GCM sealed when Encrypted is set
+	Encrypted   bool      `json:"encrypted"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Transaction tracks an asynchronously submitted transaction until its commit status is known
+type Transaction struct {
+	TxID           string    `gorm:"primaryKey" json:"tx_id"`
+	Function       string    `json:"function"`
+	AssetID        string    `gorm:"index" json:"asset_id"`
+	Submitter      string    `gorm:"index" json:"submitter"` // Format: OrgMSP::Username
+	Status         string    `json:"status"`                 // ENDORSED, SUBMITTED, COMMITTED, INVALID, FAILED
+	ValidationCode string    `json:"validation_code,omitempty"`
+	BlockNumber    uint64    `json:"block_number,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// IdempotencyKey remembers the outcome of a request sent with an Idempotency-Key header, so a
+// retry gets the original response instead of running again
+type IdempotencyKey struct {
+	Key          string    `gorm:"primaryKey" json:"key"`
+	Submitter    string    `gorm:"primaryKey" json:"submitter"` // Format: OrgMSP::Username
+	RequestHash  string    `gorm:"not null" json:"request_hash"`
+	TxID         string    `json:"tx_id"`
+	StatusCode   int       `json:"status_code"` // 0 while the first request is in flight
+	ContentType  string    `json:"content_type"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TusUpload tracks a resumable upload (tus protocol) assembled as a MinIO multipart upload. All
+// stored parts have the handler's fixed part size; the bytes past the last full part wait in a
+// "<object>.tail" object until the next chunk completes a part.
+type TusUpload struct {
+	ID          string `gorm:"primaryKey" json:"id"`
+	Owner       string `gorm:"index;not null" json:"owner"` // Format: OrgMSP::Username
+	ObjectName  string `gorm:"not null" json:"object_name"`
+	MultipartID string `gorm:"not null" json:"-"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	// Upload- prefixed as in the tus headers; OFFSET is reserved in SQL
+	UploadLength int64     `json:"length"`
+	UploadOffset int64     `json:"offset"`
+	PartETags    []string  `gorm:"column:part_etags;serializer:json" json:"-"`
+	LockedUntil  time.Time `json:"-"` // Lease held while a PATCH is being written
+	// Set once the upload is complete and verified
+	FileHash  string    `json:"file_hash"`
+	IpfsCID   string    `json:"ipfs_cid"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `gorm:"index" json:"updated_at"` // Bumped by every stored chunk; idle uploads expire by it
+}
+
 type Notification struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	UserID    string    `gorm:"index" json:"user_id"` // Format: OrgMSP::Username
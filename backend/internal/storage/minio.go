@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -64,6 +65,31 @@ func (s *MinIOStorage) Upload(objectName string, reader io.Reader, size int64, c
 	return info.Key, nil
 }
 
+// StreamPartSize is the multipart part size used for uploads of unknown length. minio-go buffers
+// one part at a time, so it bounds the memory held per upload (and objects to 10,000 parts).
+const StreamPartSize = 16 * 1024 * 1024
+
+// UploadStream stores reader as objectName without knowing its size in advance, using a
+// multipart upload
+func (s *MinIOStorage) UploadStream(ctx context.Context, objectName string, reader io.Reader, contentType string) (string, error) {
+	info, err := s.Client.PutObject(ctx, s.BucketName, objectName, reader, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    StreamPartSize,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload object: %w", err)
+	}
+	return info.Key, nil
+}
+
+// Remove deletes an object, e.g. one left behind by an upload that failed elsewhere
+func (s *MinIOStorage) Remove(ctx context.Context, objectName string) error {
+	if err := s.Client.RemoveObject(ctx, s.BucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove object: %w", err)
+	}
+	return nil
+}
+
 func (s *MinIOStorage) GetPresignedURL(objectName string, expires time.Duration, download bool) (string, error) {
 	reqParams := make(url.Values)
 	if download {
@@ -77,3 +103,69 @@ func (s *MinIOStorage) GetPresignedURL(objectName string, expires time.Duration,
 	}
 	return presignedURL.String(), nil
 }
+
+// PresignedPut returns a URL the browser can PUT objectName to directly. Content type, length
+// and the x-amz-meta-* metadata are signed, so the upload must send exactly the returned headers
+// and a body of size bytes.
+func (s *MinIOStorage) PresignedPut(ctx context.Context, objectName, contentType string, size int64, metadata map[string]string, expires time.Duration) (string, http.Header, error) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", contentType)
+	for key, value := range metadata {
+		headers.Set("X-Amz-Meta-"+key, value)
+	}
+
+	signed := headers.Clone()
+	signed.Set("Content-Length", fmt.Sprintf("%d", size))
+	presignedURL, err := s.SignerClient.PresignHeader(ctx, http.MethodPut, s.BucketName, objectName, expires, nil, signed)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+	// The browser sets Content-Length itself
+	return presignedURL.String(), headers, nil
+}
+
+// PresignedPost returns a URL and the form fields for a browser POST upload of objectName. The
+// policy pins the content type and metadata and caps the size at maxSize bytes.
+func (s *MinIOStorage) PresignedPost(ctx context.Context, objectName, contentType string, maxSize int64, metadata map[string]string, expires time.Duration) (string, map[string]string, error) {
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(s.BucketName); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetKey(objectName); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetExpires(time.Now().UTC().Add(expires)); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetContentType(contentType); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetContentLengthRange(1, maxSize); err != nil {
+		return "", nil, err
+	}
+	for key, value := range metadata {
+		if err := policy.SetUserMetadata(key, value); err != nil {
+			return "", nil, err
+		}
+	}
+
+	postURL, formData, err := s.SignerClient.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to presign upload policy: %w", err)
+	}
+	return postURL.String(), formData, nil
+}
+
+// Stat returns an object's size, content type and metadata
+func (s *MinIOStorage) Stat(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	return s.Client.StatObject(ctx, s.BucketName, objectName, minio.StatObjectOptions{})
+}
+
+// Open streams an object back from the bucket
+func (s *MinIOStorage) Open(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	object, err := s.Client.GetObject(ctx, s.BucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", err)
+	}
+	return object, nil
+}
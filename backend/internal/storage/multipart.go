@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload, except for the last one
+const MinPartSize = 5 * 1024 * 1024
+
+// NewMultipartUpload starts a multipart upload of objectName whose parts are sent one by one,
+// possibly from different requests
+func (s *MinIOStorage) NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	uploadID, err := s.core().NewMultipartUpload(ctx, s.BucketName, objectName, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart stores data as part number (from 1) of a multipart upload and returns its ETag
+func (s *MinIOStorage) UploadPart(ctx context.Context, objectName, uploadID string, number int, data []byte) (string, error) {
+	part, err := s.core().PutObjectPart(ctx, s.BucketName, objectName, uploadID, number,
+		bytes.NewReader(data), int64(len(data)), minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %w", number, err)
+	}
+	return part.ETag, nil
+}
+
+// CompleteMultipartUpload assembles the parts, given as the ETags of parts 1..n, into the final object
+func (s *MinIOStorage) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, etags []string) error {
+	completed := make([]minio.CompletePart, len(etags))
+	for i, etag := range etags {
+		completed[i] = minio.CompletePart{PartNumber: i + 1, ETag: etag}
+	}
+	if _, err := s.core().CompleteMultipartUpload(ctx, s.BucketName, objectName, uploadID, completed, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts stored so far
+func (s *MinIOStorage) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	if err := s.core().AbortMultipartUpload(ctx, s.BucketName, objectName, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *MinIOStorage) core() minio.Core {
+	return minio.Core{Client: s.Client}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+)
+
+// Sink consumes one copy of a stream; it must read until EOF or return an error
+type Sink func(r io.Reader) error
+
+// Tee copies src to every sink concurrently while computing its SHA-256, so a file is hashed and
+// stored in a single pass without being held in memory. Each sink reads through its own pipe, so
+// the copy advances at the pace of the slowest sink. A failing source or sink aborts all of them.
+func Tee(src io.Reader, sinks ...Sink) (hash string, size int64, err error) {
+	hasher := sha256.New()
+	writers := []io.Writer{hasher}
+	pipes := make([]*io.PipeWriter, 0, len(sinks))
+	results := make(chan error, len(sinks))
+
+	for _, sink := range sinks {
+		pr, pw := io.Pipe()
+		pipes = append(pipes, pw)
+		writers = append(writers, pw)
+		go func(sink Sink) {
+			err := sink(pr)
+			// Unblocks the copy if the sink stopped early
+			pr.CloseWithError(err)
+			results <- err
+		}(sink)
+	}
+
+	source := &sourceReader{r: src}
+	size, err = io.Copy(io.MultiWriter(writers...), source)
+	for _, pw := range pipes {
+		pw.CloseWithError(err) // nil closes with EOF
+	}
+
+	var sinkErr error
+	for range sinks {
+		if result := <-results; result != nil && sinkErr == nil {
+			sinkErr = result
+		}
+	}
+	// The sinks fail too when the source does, so its error is the cause
+	if source.err != nil {
+		return "", 0, source.err
+	}
+	if sinkErr != nil {
+		return "", 0, sinkErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+// sourceReader remembers the error reading the source, to tell it apart from a failing sink
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return n, err
+}
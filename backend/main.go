@@ -12,11 +12,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	shell "github.com/ipfs/go-ipfs-api"
 )
@@ -46,48 +48,63 @@ func main() {
 		cryptoPathOrg2 = "../network/crypto-config/peerOrganizations/org2.example.com"
 	}
 	
-	// Org1 Admin paths
-	certPath1 := fmt.Sprintf("%s/users/Admin@org1.example.com/msp/signcerts/Admin@org1.example.com-cert.pem", cryptoPathOrg1)
-	keyPath1 := ""
-	tlsCertPath1 := fmt.Sprintf("%s/peers/peer0.org1.example.com/tls/ca.crt", cryptoPathOrg1)
-	
-	peerEndpoint := os.Getenv("PEER_ENDPOINT")
-	if peerEndpoint == "" {
-		peerEndpoint = "localhost:7051"
-	}
 	walletPath := os.Getenv("WALLET_PATH")
 	if walletPath == "" {
 		walletPath = "./wallet"
 	}
-
-	// Find Key File (Org1 Admin)
-	keyDir1 := fmt.Sprintf("%s/users/Admin@org1.example.com/msp/keystore", cryptoPathOrg1)
-	files, err := os.ReadDir(keyDir1)
-	if err == nil {
-		for _, file := range files {
-			if file.Name() != "" {
-				keyPath1 = fmt.Sprintf("%s/%s", keyDir1, file.Name())
-				break
-			}
-		}
+	// WALLET_TYPE selects file (default), encrypted or postgres; WALLET_MASTER_KEY is a base64 AES-256 key
+	wallet, err := fabric.NewWallet(fabric.WalletConfig{
+		Type:      os.Getenv("WALLET_TYPE"),
+		Path:      walletPath,
+		MasterKey: os.Getenv("WALLET_MASTER_KEY"),
+	}, database)
+	if err != nil {
+		log.Fatalf("Failed to open wallet: %v", err)
 	}
 
 	cfg := fabric.Config{
-		CertPath:      certPath1,
-		KeyPath:       keyPath1,
-		TlsCertPath:   tlsCertPath1,
-		PeerEndpoint:  peerEndpoint,
-		GatewayPeer:   "peer0.org1.example.com",
 		ChannelName:   "mychannel",
 		ChaincodeName: "basic",
 	}
 
-	// 1. Establish gRPC Connection
-	conn, err := fabric.CreateGRPCConnection(cfg)
+	// 1. Connect to the peers of every organization described by the connection profile
+	profilePath := os.Getenv("CONNECTION_PROFILE")
+	if profilePath == "" {
+		profilePath = "./connection-profile.yaml"
+	}
+	profile, err := fabric.LoadConnectionProfile(profilePath)
+	if err != nil {
+		log.Fatalf("Failed to load connection profile: %v", err)
+	}
+	peers, err := fabric.DialPeers(profile)
+	if err != nil {
+		log.Fatalf("Failed to create gRPC connections: %v", err)
+	}
+	defer peers.Close()
+	go peers.Monitor(context.Background(), 10*time.Second)
+
+	// Gateways are cached per identity; writes through the returned wallet evict stale entries
+	gatewayCacheSize, _ := strconv.Atoi(os.Getenv("GATEWAY_CACHE_SIZE"))
+	gatewayCacheTTL, err := time.ParseDuration(os.Getenv("GATEWAY_CACHE_TTL"))
+	if err != nil {
+		gatewayCacheTTL = 15 * time.Minute
+	}
+	gateways := fabric.NewGatewayPool(peers, wallet, fabric.GatewayPoolConfig{
+		ChannelName:   cfg.ChannelName,
+		ChaincodeName: cfg.ChaincodeName,
+		MaxSize:       gatewayCacheSize,
+		TTL:           gatewayCacheTTL,
+	})
+	defer gateways.Close()
+	wallet = gateways.Wallet()
+
+	// Asset routes called with ?async=true answer 202 and are tracked in the transactions table
+	commitTimeout, err := time.ParseDuration(os.Getenv("COMMIT_STATUS_TIMEOUT"))
 	if err != nil {
-		log.Fatalf("Failed to create gRPC connection: %v", err)
+		commitTimeout = 5 * time.Minute
 	}
-	defer conn.Close()
+	transactions := fabric.NewTransactionTracker(database, commitTimeout)
+	transactionHandler := &api.TransactionHandler{Transactions: transactions}
 
 	// 2. Setup Auth Handler
 	// CA URL is usually localhost:7054 for Org1 CA
@@ -101,38 +118,73 @@ func main() {
 		ca2URL = "https://localhost:8054"
 	}
 
+	// Registrar used to sign CA admin requests (register, identities, revoke)
+	caRegistrar := os.Getenv("CA_REGISTRAR")
+	if caRegistrar == "" {
+		caRegistrar = "admin"
+	}
+	// The registrar can enroll and revoke identities, so there is no default secret
+	caRegistrarSecret := os.Getenv("CA_REGISTRAR_SECRET")
+	if caRegistrarSecret == "" {
+		log.Fatal("CA_REGISTRAR_SECRET must be set")
+	}
+
 	caCfg1 := fabric.CAConfig{
-		URL:           ca1URL,
-		MSPID:         "Org1MSP",
-		WalletPath:    walletPath,
-		AdminPath:     cryptoPathOrg1 + "/users/Admin@org1.example.com/msp",
-		CAName:        "ca-org1",
-		ContainerName: "ca_org1",
+		URL:             ca1URL,
+		MSPID:           "Org1MSP",
+		Wallet:          wallet,
+		AdminPath:       cryptoPathOrg1 + "/users/Admin@org1.example.com/msp",
+		CAName:          "ca-org1",
+		RegistrarID:     caRegistrar,
+		RegistrarSecret: caRegistrarSecret,
 	}
 	caCfg2 := fabric.CAConfig{
-		URL:           ca2URL,
-		MSPID:         "Org2MSP",
-		WalletPath:    walletPath,
-		AdminPath:     cryptoPathOrg2 + "/users/Admin@org2.example.com/msp",
-		CAName:        "ca-org2",
-		ContainerName: "ca_org2",
+		URL:             ca2URL,
+		MSPID:           "Org2MSP",
+		Wallet:          wallet,
+		AdminPath:       cryptoPathOrg2 + "/users/Admin@org2.example.com/msp",
+		CAName:          "ca-org2",
+		RegistrarID:     caRegistrar,
+		RegistrarSecret: caRegistrarSecret,
+	}
+
+	// Orderer that receives the channel config updates carrying new CRLs
+	ordererEndpoint := os.Getenv("ORDERER_ENDPOINT")
+	if ordererEndpoint == "" {
+		ordererEndpoint = "localhost:7050"
+	}
+	ordererTLSCert := os.Getenv("ORDERER_TLS_CA")
+	if ordererTLSCert == "" {
+		ordererTLSCert = "../network/crypto-config/ordererOrganizations/example.com/orderers/orderer.example.com/tls/ca.crt"
+	}
+	revoker := &fabric.Revoker{
+		Peers:       peers,
+		ChannelName: cfg.ChannelName,
+		Orderer: fabric.OrdererConfig{
+			Endpoint:     ordererEndpoint,
+			TlsCertPath:  ordererTLSCert,
+			HostOverride: "orderer.example.com",
+		},
 	}
+
 	authHandler := &api.AuthHandler{
 		CAConfigs: map[string]fabric.CAConfig{
 			"Org1MSP": caCfg1,
 			"Org2MSP": caCfg2,
 		},
-		DB: database,
+		DB:      database,
+		Revoker: revoker,
 	}
 	adminHandler := &api.AdminHandler{
 		CAConfigs: []fabric.CAConfig{
 			caCfg1,
 			caCfg2,
 		},
-		WalletPath: walletPath,
-		Config:     cfg,
-		Conn:       conn,
-		DB:         database,
+		Gateways:     gateways,
+		Transactions: transactions,
+		Peers:        peers,
+		DB:           database,
+		Revoker:      revoker,
 	}
 
 	// 4. Setup Storage Handler (IPFS + MinIO)
@@ -167,40 +219,84 @@ func main() {
 		}
 	}
 
+	// Uploads are streamed to storage, so they are only bounded by MAX_UPLOAD_SIZE (default 5 GiB);
+	// every other request body is held to BODY_LIMIT (default 10 MB)
+	bodyLimit, err := api.ParseByteSize(os.Getenv("BODY_LIMIT"))
+	if err != nil || bodyLimit == 0 {
+		bodyLimit = 10 * 1024 * 1024
+	}
+	maxUploadSize, err := api.ParseByteSize(os.Getenv("MAX_UPLOAD_SIZE"))
+	if err != nil || maxUploadSize == 0 {
+		maxUploadSize = 5 * 1024 * 1024 * 1024
+	}
+
 	storageHandler := &api.StorageHandler{
-		MinIO: minioStore,
-		Ipfs:  sh,
+		MinIO:         minioStore,
+		Ipfs:          sh,
+		MaxUploadSize: maxUploadSize,
+	}
+	tusHandler := &api.TusHandler{
+		Storage: storageHandler,
+		DB:      database,
+	}
+
+	// Resumable uploads idle for TUS_UPLOAD_TTL are aborted and their rows deleted
+	tusUploadTTL, err := time.ParseDuration(os.Getenv("TUS_UPLOAD_TTL"))
+	if err != nil || tusUploadTTL <= 0 {
+		tusUploadTTL = 24 * time.Hour
+	}
+	if minioStore != nil {
+		go tusHandler.RunExpiry(context.Background(), time.Hour, tusUploadTTL)
 	}
 
 	// SETUP SERVER
+	// Bodies over BodyLimit reach their handler as a stream; multipart forms are not pre-parsed so
+	// that uploads are never spooled to memory or temp files
 	app := fiber.New(fiber.Config{
-		BodyLimit: 10 * 1024 * 1024, // 10 MB
+		BodyLimit:                    int(bodyLimit),
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 	app.Use(cors.New(cors.Config{
 		AllowCredentials: true,
 		AllowOrigins:     "http://localhost:5173", // Frontend Dev Port
+		// Read by tus clients to resume uploads
+		ExposeHeaders: "Location,Upload-Offset,Upload-Length,Tus-Resumable,Tus-Version,Tus-Extension,Tus-Max-Size",
 	}))
+	app.Use(api.LimitBody(bodyLimit, "/api/storage/upload", "/api/storage/tus"))
 
 	// 3. START EVENTUAL CONSISTENCY LISTENER
-	// We use the Admin identity to listen for all events across the organization
-	go func() {
-		// Event listener usually runs as Org1 Admin
-		adminID, adminSign, err := fabric.GetIdentity("admin", "Org1MSP", walletPath)
-		if err != nil {
-			log.Printf("Listener Error: Could not load admin identity: %v", err)
-			return
-		}
-		
-		gw, err := fabric.CreateGateway(conn, adminID, adminSign)
-		if err != nil {
-			log.Printf("Listener Error: Could not connect to gateway: %v", err)
-			return
-		}
-		defer gw.Close()
-
-		network := gw.GetNetwork(cfg.ChannelName)
-		fabric.StartEventListener(context.Background(), network, database)
-	}()
+	// The listener runs as Org1 Admin, reconnects on failure and resumes from its checkpoint
+	listener := fabric.NewEventListener(fabric.ListenerConfig{
+		ChannelName:    cfg.ChannelName,
+		ChaincodeName:  cfg.ChaincodeName,
+		Username:       "admin",
+		MSPID:          "Org1MSP",
+		Wallet:         wallet,
+		CheckpointFile: os.Getenv("LISTENER_CHECKPOINT_FILE"),
+		MinBackoff:     time.Second,
+		MaxBackoff:     time.Minute,
+	}, peers, database)
+	go listener.Run(context.Background())
+	adminHandler.Listener = listener
+
+	// Certificate expiry monitor: reports wallet identities close to NotAfter and re-enrolls them
+	expiryWindow, err := time.ParseDuration(os.Getenv("CERT_EXPIRY_WINDOW"))
+	if err != nil {
+		expiryWindow = 30 * 24 * time.Hour
+	}
+	expiryInterval, err := time.ParseDuration(os.Getenv("CERT_EXPIRY_SCAN_INTERVAL"))
+	if err != nil {
+		expiryInterval = time.Hour
+	}
+	expiryMonitor := fabric.NewExpiryMonitor(fabric.ExpiryMonitorConfig{
+		CAConfigs: []fabric.CAConfig{caCfg1, caCfg2},
+		Interval:  expiryInterval,
+		Window:    expiryWindow,
+		AutoRenew: os.Getenv("CERT_AUTO_REENROLL") != "false",
+	})
+	go expiryMonitor.Run(context.Background())
+	adminHandler.Expiry = expiryMonitor
 
 	// PUBLIC ROUTES
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -214,8 +310,19 @@ func main() {
 
 	// STORAGE ROUTES
 	app.Post("/api/storage/upload", auth.Middleware(), storageHandler.Upload)
+	app.Post("/api/storage/upload-many", auth.Middleware(), storageHandler.UploadMany)
+	app.Post("/api/storage/presign", auth.Middleware(), storageHandler.Presign)
+	app.Post("/api/storage/complete", auth.Middleware(), storageHandler.Complete)
 	app.Get("/api/storage/url/:objectName", auth.Middleware(), storageHandler.GetURL)
 
+	// Resumable uploads (tus 1.0). GET returns the attachment once the upload is complete.
+	app.Options("/api/storage/tus", tusHandler.Options)
+	app.Post("/api/storage/tus", auth.Middleware(), tusHandler.Create)
+	app.Head("/api/storage/tus/:id", auth.Middleware(), tusHandler.Head)
+	app.Patch("/api/storage/tus/:id", auth.Middleware(), tusHandler.Patch)
+	app.Delete("/api/storage/tus/:id", auth.Middleware(), tusHandler.Terminate)
+	app.Get("/api/storage/tus/:id", auth.Middleware(), tusHandler.Attachment)
+
 	// OPA MIDDLEWARE: Centralized AuthZ delegation
 	app.Use(func(c *fiber.Ctx) error {
 		// Only apply to protected sub-groups or API routes
@@ -251,37 +358,26 @@ func main() {
 	adminGroup.Get("/stats", adminHandler.GetStats)
 	adminGroup.Get("/users", adminHandler.GetUsers)
 	adminGroup.Post("/users/:username/status", adminHandler.UpdateUserStatus)
+	adminGroup.Post("/crl/:org", adminHandler.PublishCRL)
 	adminGroup.Get("/assets", adminHandler.GetAdminAssets)
 	adminGroup.Post("/assets/:id/status", adminHandler.UpdateAssetStatus)
+	adminGroup.Post("/assets/migrate-keys", adminHandler.MigrateAssetKeys)
 	adminGroup.Post("/sync", adminHandler.Sync)
+	adminGroup.Get("/listener", adminHandler.GetListenerStatus)
+	adminGroup.Get("/peers", adminHandler.GetPeerHealth)
+	adminGroup.Get("/identities/expiring", adminHandler.GetExpiringIdentities)
+	adminGroup.Post("/identities/:org/:username/reenroll", adminHandler.ReenrollIdentity)
 
 	// PROTECTED ROUTES
-	api := app.Group("/assets", auth.Middleware())
+	assetGroup := app.Group("/assets", auth.Middleware())
 
 	// Helper to get Contract for the logged-in user
-	getContract := func(c *fiber.Ctx) (*client.Gateway, *client.Contract, error) {
-		username := c.Locals("user").(string)
-		org := c.Locals("org").(string)
-		
-		// Load Identity from Wallet
-		id, sign, err := fabric.GetIdentity(username, org, walletPath)
-		if err != nil {
-			return nil, nil, fmt.Errorf("identity not found for user %s (%s): %v", username, org, err)
-		}
-
-		// Create Gateway for this request
-		gw, err := fabric.CreateGateway(conn, id, sign)
-		if err != nil {
-			return nil, nil, err
-		}
-		
-		network := gw.GetNetwork(cfg.ChannelName)
-		contract := network.GetContract(cfg.ChaincodeName)
-		return gw, contract, nil
+	getContract := func(c *fiber.Ctx) (*fabric.GatewayLease, *client.Contract, error) {
+		return api.CallerContract(c, gateways)
 	}
 
 	// Asset Routes (Protected)
-	api.Get("/", func(c *fiber.Ctx) error {
+	assetGroup.Get("/", func(c *fiber.Ctx) error {
 		user := c.Locals("user").(string)
 		role := c.Locals("role").(string)
 		org := c.Locals("org").(string)
@@ -298,7 +394,7 @@ func main() {
 
 			var assets []models.Asset
 			// Logic: Show if (PUBLIC OR Owner OR ProposedOwner) AND NOT DELETED
-			err := database.Where("(UPPER(view) = 'PUBLIC' OR owner_id = ? OR proposed_owner_id = ?) AND status != 'DELETED'", fullID, fullID).Find(&assets).Error
+			err := fabric.OrderedAttachments(database).Where("(UPPER(view) = 'PUBLIC' OR owner_id = ? OR proposed_owner_id = ?) AND status != 'DELETED'", fullID, fullID).Find(&assets).Error
 			if err != nil {
 				return c.Status(500).SendString("Database error: " + err.Error())
 			}
@@ -306,40 +402,89 @@ func main() {
 		}
 
 		// Admin gets blockchain
+		query, err := api.ParseAssetQuery(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		gw, contract, err := getContract(c)
 		if err != nil {
 			return c.Status(401).SendString(err.Error())
 		}
 		defer gw.Close()
 
-		result, err := contract.EvaluateTransaction("GetAllAssets")
-		if err != nil {
-			return c.Status(500).SendString(err.Error())
+		// Plain listings keep the array shape and return every asset, read in the largest pages
+		if c.Query("limit") == "" && c.Query("bookmark") == "" {
+			query.PageSize = api.MaxPageSize
+			records, err := fabric.QueryAllAssets(contract, query)
+			if err != nil {
+				return api.ContractError(c, err)
+			}
+			return c.JSON(api.FlattenPage(&models.PaginatedQueryResult{Records: records}))
 		}
 
-		var ledgerValues []models.LedgerValue
-		if err := json.Unmarshal(result, &ledgerValues); err != nil {
-			// Fallback
-			return c.Type("json").Send(result)
+		// Callers that page explicitly get one page and the bookmark back
+		page, err := fabric.QueryAssets(contract, query)
+		if err != nil {
+			return api.ContractError(c, err)
 		}
 
-		var assets []models.Asset
-		for _, val := range ledgerValues {
-			asset := val.Asset
-			asset.Action = val.Audit.Action
-			asset.LastUpdatedBy = val.Audit.Actor
-			if val.Audit.Timestamp != "" {
-				t, err := time.Parse(time.RFC3339, val.Audit.Timestamp)
-				if err == nil {
-					asset.LastUpdatedAt = t
-				}
+		assets := api.FlattenPage(page)
+		return c.JSON(fiber.Map{
+			"assets":        assets,
+			"bookmark":      page.Bookmark,
+			"fetched_count": page.FetchedRecordsCount,
+		})
+	})
+
+	// Ledger listings through the chaincode's owner~asset and status~asset indexes, independent of
+	// the Postgres projection. Non-admins see the same subset as in the projection listing.
+	listByIndex := func(lookup func(contract *client.Contract, value string, pageSize int32, bookmark string) (*models.PaginatedQueryResult, error), param string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			user := c.Locals("user").(string)
+			role := c.Locals("role").(string)
+			org := c.Locals("org").(string)
+
+			allowed, err := auth.CheckAuthorization(user, role, org, c.Method(), c.Path())
+			if err != nil || !allowed {
+				return c.Status(403).JSON(fiber.Map{"error": "Permission denied by OPA"})
+			}
+
+			query, err := api.ParseAssetQuery(c)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+
+			gw, contract, err := getContract(c)
+			if err != nil {
+				return c.Status(401).SendString(err.Error())
+			}
+			defer gw.Close()
+
+			page, err := lookup(contract, c.Params(param), query.PageSize, query.Bookmark)
+			if err != nil {
+				return api.ContractError(c, err)
+			}
+
+			assets := api.FlattenPage(page)
+			if role != "admin" {
+				assets = api.VisibleAssets(assets, fmt.Sprintf("%s::%s", org, user))
 			}
-			assets = append(assets, asset)
+			return c.JSON(fiber.Map{
+				"source":        "blockchain",
+				"assets":        assets,
+				"bookmark":      page.Bookmark,
+				"fetched_count": page.FetchedRecordsCount,
+			})
 		}
-		return c.JSON(assets)
-	})
+	}
+	assetGroup.Get("/by-owner/:owner", listByIndex(fabric.AssetsByOwner, "owner"))
+	assetGroup.Get("/by-status/:status", listByIndex(func(contract *client.Contract, status string, pageSize int32, bookmark string) (*models.PaginatedQueryResult, error) {
+		return fabric.AssetsByStatus(contract, strings.ToUpper(status), pageSize, bookmark)
+	}, "status"))
 
-	api.Post("/", func(c *fiber.Ctx) error {
+	// Clients may send an Idempotency-Key so that retrying a create replays the first result
+	assetGroup.Post("/", api.Idempotent(database), func(c *fiber.Ctx) error {
 		type CreateReq struct {
 			ID          string `json:"id"`
 			Name        string `json:"name"`
@@ -359,22 +504,61 @@ func main() {
 			return c.Status(400).SendString(err.Error())
 		}
 
-		gw, contract, err := getContract(c)
+		gw, _, err := getContract(c)
 		if err != nil {
 			return c.Status(401).SendString(err.Error())
 		}
 		defer gw.Close()
 
+		submission := api.Submission{
+			Function: "CreateAsset",
+			AssetID:  req.ID,
+			Options: []client.ProposalOption{
+				client.WithArguments(req.ID, req.Name, req.Description, req.ImageURL, req.ImageHash, req.View,
+					req.FileName, fmt.Sprintf("%d", req.FileSize), req.FileHash, req.IpfsCID, req.StoragePath, req.StorageType),
+			},
+		}
 
-		_, err = contract.SubmitTransaction("CreateAsset", req.ID, req.Name, req.Description, req.ImageURL, req.ImageHash, req.View,
-			req.FileName, fmt.Sprintf("%d", req.FileSize), req.FileHash, req.IpfsCID, req.StoragePath, req.StorageType)
-		if err != nil {
-			return c.Status(500).SendString(err.Error())
+		// PRIVATE details travel as transient data so they never appear in the proposal arguments
+		if strings.ToUpper(req.View) == "PRIVATE" {
+			details := models.AssetPrivateDetails{
+				ID:          req.ID,
+				Name:        req.Name,
+				Description: req.Description,
+				ImageURL:    req.ImageURL,
+				ImageHash:   req.ImageHash,
+			}
+			if req.FileName != "" || req.FileHash != "" {
+				details.Attachments = []models.AssetAttachment{{
+					FileName:    req.FileName,
+					FileSize:    req.FileSize,
+					FileHash:    req.FileHash,
+					IpfsCID:     req.IpfsCID,
+					StoragePath: req.StoragePath,
+					StorageType: req.StorageType,
+				}}
+			}
+			transient, err := fabric.PrivateDetailsTransient(details)
+			if err != nil {
+				return c.Status(500).SendString(err.Error())
+			}
+
+			submission.Options = []client.ProposalOption{
+				client.WithArguments(req.ID, "", "", "", "", "PRIVATE", "", "0", "", "", "", ""),
+				client.WithTransient(transient),
+				client.WithEndorsingOrganizations(c.Locals("org").(string)),
+			}
+		}
+
+		// Without an ID the chaincode generates one from the transaction ID
+		responded, id, err := api.SubmitAsset(c, transactions, gw, submission)
+		if responded {
+			return err
 		}
-		return c.SendString("Asset Created")
+		return c.JSON(fiber.Map{"message": "Asset Created", "id": id})
 	})
 
-	api.Get("/:id", func(c *fiber.Ctx) error {
+	assetGroup.Get("/:id", func(c *fiber.Ctx) error {
 		id := c.Params("id")
 		role := c.Locals("role").(string)
 
@@ -385,7 +569,7 @@ func main() {
 			fullID := fmt.Sprintf("%s::%s", org, username)
 
 			var asset models.Asset
-			if err := database.Where("id = ?", id).First(&asset).Error; err != nil {
+			if err := fabric.OrderedAttachments(database).Where("id = ?", id).First(&asset).Error; err != nil {
 				return c.Status(404).JSON(fiber.Map{"error": "Asset not found"})
 			}
 
@@ -405,7 +589,7 @@ func main() {
 
 		result, err := contract.EvaluateTransaction("ReadAsset", id)
 		if err != nil {
-			return c.Status(404).SendString(err.Error())
+			return api.ContractError(c, err)
 		}
 
 		var val models.LedgerValue
@@ -427,7 +611,7 @@ func main() {
 	})
 
 	// Blockchain Verification Route (Direct Ledger access)
-	api.Get("/:id/blockchain", func(c *fiber.Ctx) error {
+	assetGroup.Get("/:id/blockchain", func(c *fiber.Ctx) error {
 		id := c.Params("id")
 		role := c.Locals("role").(string)
 		username := c.Locals("user").(string)
@@ -455,7 +639,7 @@ func main() {
 
 		result, err := contract.EvaluateTransaction("ReadAsset", id)
 		if err != nil {
-			return c.Status(404).SendString("Blockchain Read Error: " + err.Error())
+			return api.ContractError(c, err)
 		}
 
 		// Return raw unmarshaled LedgerValue
@@ -466,21 +650,21 @@ func main() {
 		return c.JSON(val)
 	})
 
-	api.Get("/:id/history", func(c *fiber.Ctx) error {
+	assetGroup.Get("/:id/history", func(c *fiber.Ctx) error {
 		id := c.Params("id")
 		role := c.Locals("role").(string)
 		username := c.Locals("user").(string)
 		org := c.Locals("org").(string)
 		fullID := fmt.Sprintf("%s::%s", org, username)
 
-		// Access check: Admin OR Owner
-		if role != "admin" {
+		// Access check: Admin, Auditor OR Owner (the chaincode enforces the same rule via the role attribute)
+		if role != "admin" && role != "auditor" {
 			var asset models.Asset
 			if err := database.Where("id = ?", id).First(&asset).Error; err != nil {
 				return c.Status(404).JSON(fiber.Map{"error": "Asset not found"})
 			}
 			if asset.OwnerID != fullID && asset.ProposedOwnerID != fullID {
-				return c.Status(403).JSON(fiber.Map{"error": "Provenance history is restricted to the owner, proposed owner, or administrators."})
+				return c.Status(403).JSON(fiber.Map{"error": "Provenance history is restricted to the owner, proposed owner, auditors or administrators."})
 			}
 		}
 
@@ -492,13 +676,209 @@ func main() {
 
 		result, err := contract.EvaluateTransaction("GetAssetHistory", id)
 		if err != nil {
-			return c.Status(500).SendString(err.Error())
+			return api.ContractError(c, err)
 		}
 		return c.Type("json").Send(result)
 	})
 
-	api.Post("/:id/view", func(c *fiber.Ctx) error {
+	// Statuses the asset may move to next, per the chaincode's transition table
+	assetGroup.Get("/:id/transitions", func(c *fiber.Ctx) error {
 		id := c.Params("id")
+
+		gw, contract, err := getContract(c)
+		if err != nil {
+			return c.Status(401).SendString(err.Error())
+		}
+		defer gw.Close()
+
+		result, err := contract.EvaluateTransaction("GetAllowedTransitions", id)
+		if err != nil {
+			return api.ContractError(c, err)
+		}
+
+		var transitions []string
+		if err := json.Unmarshal(result, &transitions); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return c.JSON(fiber.Map{"id": id, "transitions": transitions})
+	})
+
+	// Private details of a PRIVATE asset, read from the caller's own org collection
+	assetGroup.Get("/:id/private", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		gw, contract, err := getContract(c)
+		if err != nil {
+			return c.Status(401).SendString(err.Error())
+		}
+		defer gw.Close()
+
+		result, err := contract.Evaluate("ReadAssetPrivateDetails",
+			client.WithArguments(id),
+			client.WithEndorsingOrganizations(c.Locals("org").(string)),
+		)
+		if err != nil {
+			return api.ContractError(c, err)
+		}
+
+		var details models.AssetPrivateDetails
+		if err := json.Unmarshal(result, &details); err != nil {
+			return c.Type("json").Send(result)
+		}
+		details.Salt = ""
+		return c.JSON(details)
+	})
+
+	assetGroup.Patch("/:id", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+		// Omitted fields are left unchanged; "attachments" replaces the whole list
+		type MetadataReq struct {
+			Name        *string                   `json:"name"`
+			Description *string                   `json:"desc"`
+			ImageURL    *string                   `json:"image_url"`
+			ImageHash   *string                   `json:"image_hash"`
+			Attachments *[]models.AssetAttachment `json:"attachments"`
+		}
+		req := new(MetadataReq)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+
+		gw, _, err := getContract(c)
+		if err != nil {
+			return c.Status(401).SendString(err.Error())
+		}
+		defer gw.Close()
+
+		// PRIVATE details are rewritten in the owner's collection, so endorse on the owner's org peer
+		update := models.AssetMetadataUpdate{
+			Name:        req.Name,
+			Description: req.Description,
+			ImageURL:    req.ImageURL,
+			ImageHash:   req.ImageHash,
+			Attachments: req.Attachments,
+		}
+		transient, err := fabric.MetadataUpdateTransient(update)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "UpdateAssetMetadata",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id, ""),
+				client.WithTransient(transient),
+				client.WithEndorsingOrganizations(c.Locals("org").(string)),
+			},
+			OnCommit: func(string) {
+				// Project the committed asset rather than the request, which the chaincode trims and merges
+				result, err := gw.Contract().EvaluateTransaction("ReadAsset", id)
+				if err != nil {
+					log.Printf("Projection Warning: failed to read asset %s after UpdateAssetMetadata: %v", id, err)
+					return
+				}
+				var val models.LedgerValue
+				if err := json.Unmarshal(result, &val); err == nil {
+					asset := val.ToAsset()
+					fabric.SaveAsset(database, &asset)
+				}
+			},
+		})
+		if responded {
+			return err
+		}
+
+		return c.SendString("Asset Metadata Updated")
+	})
+
+	// changeAttachments submits an attachment transaction on the owner's org peer (PRIVATE details
+	// live in its collection) and projects the resulting ledger value. The event listener projects
+	// the same event, so the change itself is never replayed on the stored list.
+	changeAttachments := func(c *fiber.Ctx, id string, function string, options []client.ProposalOption) error {
+		gw, _, err := getContract(c)
+		if err != nil {
+			return c.Status(401).SendString(err.Error())
+		}
+		defer gw.Close()
+
+		options = append(options, client.WithEndorsingOrganizations(c.Locals("org").(string)))
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: function,
+			AssetID:  id,
+			Options:  options,
+			OnCommit: func(string) {
+				result, err := gw.Contract().EvaluateTransaction("ReadAsset", id)
+				if err != nil {
+					log.Printf("Projection Warning: failed to read asset %s after %s: %v", id, function, err)
+					return
+				}
+				var val models.LedgerValue
+				if err := json.Unmarshal(result, &val); err == nil {
+					asset := val.ToAsset()
+					fabric.SaveAsset(database, &asset)
+				}
+			},
+		})
+		if responded {
+			return err
+		}
+
+		return c.SendString("Asset Attachments Updated")
+	}
+
+	assetGroup.Post("/:id/attachments", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+		attachment := new(models.AssetAttachment)
+		if err := c.BodyParser(attachment); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+
+		transient, err := fabric.AttachmentTransient(*attachment)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return changeAttachments(c, id, "AddAttachment",
+			[]client.ProposalOption{client.WithArguments(id, ""), client.WithTransient(transient)})
+	})
+
+	// The index addresses the ledger's attachment order; current_hash must match the file there
+	assetGroup.Put("/:id/attachments/:index", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+		index, err := c.ParamsInt("index")
+		if err != nil || index < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid attachment index"})
+		}
+		type ReplaceReq struct {
+			CurrentHash string                 `json:"current_hash"`
+			Attachment  models.AssetAttachment `json:"attachment"`
+		}
+		req := new(ReplaceReq)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+
+		transient, err := fabric.AttachmentTransient(req.Attachment)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return changeAttachments(c, id, "ReplaceAttachment",
+			[]client.ProposalOption{client.WithArguments(id, strconv.Itoa(index), req.CurrentHash, ""), client.WithTransient(transient)})
+	})
+
+	assetGroup.Delete("/:id/attachments/:index", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+		index, err := c.ParamsInt("index")
+		if err != nil || index < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid attachment index"})
+		}
+		currentHash := utils.CopyString(c.Query("current_hash"))
+
+		return changeAttachments(c, id, "RemoveAttachment",
+			[]client.ProposalOption{client.WithArguments(id, strconv.Itoa(index), currentHash)})
+	})
+
+	assetGroup.Post("/:id/view", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
 		type ViewReq struct {
 			View string `json:"view"` // Public, Private
 		}
@@ -507,31 +887,46 @@ func main() {
 			return c.Status(400).SendString(err.Error())
 		}
 
-		gw, contract, err := getContract(c)
+		gw, _, err := getContract(c)
 		if err != nil {
 			return c.Status(401).SendString(err.Error())
 		}
 		defer gw.Close()
 
-		_, err = contract.SubmitTransaction("UpdateAssetView", id, req.View)
+		// Moving details in or out of the private collection must run on the owner's org peer
+		transient, err := fabric.PrivateDetailsTransient(models.AssetPrivateDetails{ID: id})
 		if err != nil {
 			return c.Status(500).SendString(err.Error())
 		}
-
-		// Update in DB
-		var asset models.Asset
-		if err := database.Where("id = ?", id).First(&asset).Error; err == nil {
-			asset.View = req.View
-			database.Save(&asset)
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "UpdateAssetView",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id, req.View),
+				client.WithTransient(transient),
+				client.WithEndorsingOrganizations(c.Locals("org").(string)),
+			},
+			OnCommit: func(string) {
+				// Update in DB (the listener replaces the details with the ledger's copy)
+				var asset models.Asset
+				if err := database.Where("id = ?", id).First(&asset).Error; err == nil {
+					asset.View = req.View
+					database.Save(&asset)
+				}
+			},
+		})
+		if responded {
+			return err
 		}
 
 		return c.SendString("Asset Visibility Updated to " + req.View)
 	})
 
-	api.Post("/:id/transfer", func(c *fiber.Ctx) error {
-		id := c.Params("id")
+	assetGroup.Post("/:id/transfer", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
 		type TransferReq struct {
 			TargetUser string `json:"target_user"`
+			ExpiresAt  string `json:"expires_at"` // Optional RFC3339 deadline
 		}
 		req := new(TransferReq)
 		if err := c.BodyParser(req); err != nil {
@@ -554,40 +949,48 @@ func main() {
 		
 		fullTargetID := fmt.Sprintf("%s::%s", targetUser.Org, targetUser.Username)
 
-		gw, contract, err := getContract(c)
+		gw, _, err := getContract(c)
 		if err != nil {
 			return c.Status(401).SendString(err.Error())
 		}
 		defer gw.Close()
 
-		_, err = contract.SubmitTransaction("ProposeTransfer", id, fullTargetID)
-		if err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-
-		// Create Notification for Target User
 		senderUsername := c.Locals("user").(string)
 		senderOrg := c.Locals("org").(string)
 		fullSenderID := fmt.Sprintf("%s::%s", senderOrg, senderUsername)
 
-		database.Create(&models.Notification{
-			UserID:  fullTargetID,
-			Title:   "Incoming Artifact Transfer",
-			Message: fmt.Sprintf("%s has proposed an artifact transfer: %s", fullSenderID, id),
-			Type:    "info",
-			Link:    fmt.Sprintf("/assets/%s", id),
+		// Sharing PRIVATE details with the recipient's org reads the owner's collection
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "ProposeTransfer",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id, fullTargetID, req.ExpiresAt),
+				client.WithEndorsingOrganizations(senderOrg),
+			},
+			OnCommit: func(string) {
+				// Create Notification for Target User
+				database.Create(&models.Notification{
+					UserID:  fullTargetID,
+					Title:   "Incoming Artifact Transfer",
+					Message: fmt.Sprintf("%s has proposed an artifact transfer: %s", fullSenderID, id),
+					Type:    "info",
+					Link:    fmt.Sprintf("/assets/%s", id),
+				})
+			},
 		})
+		if responded {
+			return err
+		}
 
 		return c.SendString("Transfer Proposed to " + fullTargetID)
 	})
 
-	api.Post("/:id/accept", func(c *fiber.Ctx) error {
-		id := c.Params("id")
+	assetGroup.Post("/:id/accept", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
 		
 		// Get Asset to know the current owner for notification
 		var asset models.Asset
 		database.Where("id = ?", id).First(&asset)
-		oldOwner := asset.OwnerID
 
 		gw, contract, err := getContract(c)
 		if err != nil {
@@ -595,29 +998,43 @@ func main() {
 		}
 		defer gw.Close()
 
-		_, err = contract.SubmitTransaction("AcceptTransfer", id)
-		if err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
+		endorsingOrgs := fabric.AssetEndorsers(contract, id, c.Locals("org").(string), &asset)
+		oldOwner := asset.OwnerID
 
-		// Create Notification for Previous Owner
-		currentUsername := c.Locals("user").(string)
 		currentOrg := c.Locals("org").(string)
+		currentUsername := c.Locals("user").(string)
 		fullCurrentID := fmt.Sprintf("%s::%s", currentOrg, currentUsername)
 
-		database.Create(&models.Notification{
-			UserID:  oldOwner,
-			Title:   "Transfer Complete",
-			Message: fmt.Sprintf("%s has accepted the transfer of %s", fullCurrentID, id),
-			Type:    "success",
-			Link:    fmt.Sprintf("/gallery/%s", id),
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "AcceptTransfer",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id),
+				client.WithEndorsingOrganizations(endorsingOrgs...),
+			},
+			OnCommit: func(string) {
+				// Create Notification for Previous Owner
+				database.Create(&models.Notification{
+					UserID:  oldOwner,
+					Title:   "Transfer Complete",
+					Message: fmt.Sprintf("%s has accepted the transfer of %s", fullCurrentID, id),
+					Type:    "success",
+					Link:    fmt.Sprintf("/gallery/%s", id),
+				})
+			},
 		})
+		if responded {
+			return err
+		}
 
 		return c.SendString("Transfer Accepted")
 	})
 
-	api.Delete("/:id", func(c *fiber.Ctx) error {
-		id := c.Params("id")
+	assetGroup.Post("/:id/reject", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+
+		var asset models.Asset
+		database.Where("id = ?", id).First(&asset)
 
 		gw, contract, err := getContract(c)
 		if err != nil {
@@ -625,22 +1042,162 @@ func main() {
 		}
 		defer gw.Close()
 
-		_, err = contract.SubmitTransaction("DeleteAsset", id)
+		endorsingOrgs := fabric.AssetEndorsers(contract, id, c.Locals("org").(string), &asset)
+		owner := asset.OwnerID
+
+		fullCurrentID := fmt.Sprintf("%s::%s", c.Locals("org").(string), c.Locals("user").(string))
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "RejectTransfer",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id),
+				client.WithEndorsingOrganizations(endorsingOrgs...),
+			},
+			OnCommit: func(string) {
+				// Let the owner know the recipient declined
+				database.Create(&models.Notification{
+					UserID:  owner,
+					Title:   "Transfer Rejected",
+					Message: fmt.Sprintf("%s has rejected the transfer of %s", fullCurrentID, id),
+					Type:    "warning",
+					Link:    fmt.Sprintf("/assets/%s", id),
+				})
+			},
+		})
+		if responded {
+			return err
+		}
+
+		return c.SendString("Transfer Rejected")
+	})
+
+	assetGroup.Post("/:id/cancel", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+
+		var asset models.Asset
+		database.Where("id = ?", id).First(&asset)
+
+		gw, contract, err := getContract(c)
 		if err != nil {
-			return c.Status(500).SendString(err.Error())
+			return c.Status(401).SendString(err.Error())
 		}
+		defer gw.Close()
+
+		endorsingOrgs := fabric.AssetEndorsers(contract, id, c.Locals("org").(string), &asset)
+		recipient := asset.ProposedOwnerID
+
+		fullCurrentID := fmt.Sprintf("%s::%s", c.Locals("org").(string), c.Locals("user").(string))
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "CancelTransfer",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id),
+				client.WithEndorsingOrganizations(endorsingOrgs...),
+			},
+			OnCommit: func(string) {
+				// Let the recipient know the offer was withdrawn
+				database.Create(&models.Notification{
+					UserID:  recipient,
+					Title:   "Transfer Cancelled",
+					Message: fmt.Sprintf("%s has cancelled the transfer of %s", fullCurrentID, id),
+					Type:    "warning",
+					Link:    fmt.Sprintf("/gallery/%s", id),
+				})
+			},
+		})
+		if responded {
+			return err
+		}
+
+		return c.SendString("Transfer Cancelled")
+	})
+
+	assetGroup.Post("/:id/expire", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
 
-		// Soft Delete in DB to maintain consistency
 		var asset models.Asset
-		if err := database.Where("id = ?", id).First(&asset).Error; err == nil {
-			asset.Status = "DELETED"
-			asset.View = "PRIVATE"
-			database.Save(&asset)
+		database.Where("id = ?", id).First(&asset)
+
+		gw, contract, err := getContract(c)
+		if err != nil {
+			return c.Status(401).SendString(err.Error())
+		}
+		defer gw.Close()
+
+		endorsingOrgs := fabric.AssetEndorsers(contract, id, c.Locals("org").(string), &asset)
+
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "ExpireTransfer",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id),
+				client.WithEndorsingOrganizations(endorsingOrgs...),
+			},
+			OnCommit: func(string) {
+				// Both parties learn that the proposal lapsed
+				for _, userID := range []string{asset.OwnerID, asset.ProposedOwnerID} {
+					if userID == "" {
+						continue
+					}
+					database.Create(&models.Notification{
+						UserID:  userID,
+						Title:   "Transfer Expired",
+						Message: fmt.Sprintf("The transfer proposal for %s expired and the artifact is ACTIVE again", id),
+						Type:    "warning",
+						Link:    fmt.Sprintf("/assets/%s", id),
+					})
+				}
+			},
+		})
+		if responded {
+			return err
+		}
+
+		return c.SendString("Transfer Expired")
+	})
+
+	assetGroup.Delete("/:id", func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Params("id"))
+
+		gw, _, err := getContract(c)
+		if err != nil {
+			return c.Status(401).SendString(err.Error())
+		}
+		defer gw.Close()
+
+		transient, err := fabric.PrivateDetailsTransient(models.AssetPrivateDetails{ID: id})
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		responded, _, err := api.SubmitAsset(c, transactions, gw, api.Submission{
+			Function: "DeleteAsset",
+			AssetID:  id,
+			Options: []client.ProposalOption{
+				client.WithArguments(id),
+				client.WithTransient(transient),
+				client.WithEndorsingOrganizations(c.Locals("org").(string)),
+			},
+			OnCommit: func(string) {
+				// Soft Delete in DB to maintain consistency
+				var asset models.Asset
+				if err := database.Where("id = ?", id).First(&asset).Error; err == nil {
+					asset.Status = "DELETED"
+					asset.View = "PRIVATE"
+					database.Save(&asset)
+				}
+			},
+		})
+		if responded {
+			return err
 		}
 
 		return c.SendString("Asset Deleted")
 	})
 
+	// TRANSACTIONS
+	// Commit status of asynchronously submitted transactions
+	app.Get("/tx/:txid", auth.Middleware(), transactionHandler.GetTransaction)
+
 	// NOTIFICATIONS
 	notifGroup := app.Group("/notifications", auth.Middleware())
 	notifGroup.Get("/", func(c *fiber.Ctx) error {
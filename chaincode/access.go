@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RoleAttribute is the CA attribute (registered as role=<role>:ecert) carrying the caller's role
+const RoleAttribute = "role"
+
+// Constants for caller roles
+const (
+	AdminRole   = "admin"
+	AuditorRole = "auditor"
+	UserRole    = "user"
+)
+
+// hasRole reports whether the caller's certificate carries one of the given roles
+func hasRole(ctx contractapi.TransactionContextInterface, roles ...string) bool {
+	for _, role := range roles {
+		if ctx.GetClientIdentity().AssertAttributeValue(RoleAttribute, role) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// requireRole fails unless the caller's certificate carries one of the given roles
+func requireRole(ctx contractapi.TransactionContextInterface, roles ...string) error {
+	if !hasRole(ctx, roles...) {
+		return newError(PermissionDenied, "access denied: requires %s=%s", RoleAttribute, strings.Join(roles, " or "))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestAuditorsAreReadOnly(t *testing.T) {
+	l := newTestLedger()
+	_, err := l.contract.CreateAsset(l.as("Org2MSP", "eve", RoleAttribute, AuditorRole), "a1", "n", "d", "", "", PublicView, "", 0, "", "", "", "")
+	expectError(t, err, "read-only")
+
+	if _, err := l.contract.CreateAsset(l.as("Org2MSP", "bob", RoleAttribute, UserRole), "a1", "n", "d", "", "", PublicView, "", 0, "", "", "", ""); err != nil {
+		t.Fatalf("CreateAsset by user role: %v", err)
+	}
+}
+
+func TestGetAssetHistoryAccess(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+
+	allowed := map[string]func() error{
+		"owner": func() error {
+			_, err := l.contract.GetAssetHistory(l.as("Org1MSP", "alice"), "a1")
+			return err
+		},
+		"proposed owner": func() error {
+			_, err := l.contract.GetAssetHistory(l.as("Org2MSP", "bob"), "a1")
+			return err
+		},
+		"admin": func() error {
+			_, err := l.contract.GetAssetHistory(l.asAdmin(), "a1")
+			return err
+		},
+		"auditor": func() error {
+			_, err := l.contract.GetAssetHistory(l.as("Org2MSP", "eve", RoleAttribute, AuditorRole), "a1")
+			return err
+		},
+	}
+	for name, call := range allowed {
+		if err := call(); err != nil {
+			t.Fatalf("%s: GetAssetHistory: %v", name, err)
+		}
+	}
+
+	_, err := l.contract.GetAssetHistory(l.as("Org1MSP", "carol", RoleAttribute, UserRole), "a1")
+	expectError(t, err, "restricted")
+}
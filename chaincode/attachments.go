@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransientAttachmentKey is the transient map key carrying the AssetAttachment for AddAttachment
+// and ReplaceAttachment when the argument is empty
+const TransientAttachmentKey = "asset_attachment"
+
+// MaxAttachments bounds the attachments of one asset
+const MaxAttachments = 20
+
+// AddAttachment appends an attachment to an ACTIVE asset. attachmentJSON is an AssetAttachment;
+// when it is empty the attachment is read from the transient map under asset_attachment, as
+// the backend does for PRIVATE assets. Only the owner may call it.
+func (s *SmartContract) AddAttachment(ctx contractapi.TransactionContextInterface, id string, attachmentJSON string) error {
+	var attachment AssetAttachment
+	if err := readJSONInput(ctx, attachmentJSON, TransientAttachmentKey, &attachment); err != nil {
+		return err
+	}
+	if err := validateNewAttachment(attachment); err != nil {
+		return err
+	}
+
+	return s.changeAttachments(ctx, id, AttachmentAddActionType, "AddAttachment", func(attachments []AssetAttachment) ([]AssetAttachment, error) {
+		if len(attachments) >= MaxAttachments {
+			return nil, newError(InvalidArgument, "asset %s already has the maximum of %d attachments", id, MaxAttachments)
+		}
+		return append(attachments, attachment), nil
+	})
+}
+
+// ReplaceAttachment swaps the attachment at index for a new version, keeping its position.
+// currentHash must be the file hash of the attachment being replaced, so a client working from
+// a stale list can't replace the wrong file.
+func (s *SmartContract) ReplaceAttachment(ctx contractapi.TransactionContextInterface, id string, index int, currentHash string, attachmentJSON string) error {
+	var attachment AssetAttachment
+	if err := readJSONInput(ctx, attachmentJSON, TransientAttachmentKey, &attachment); err != nil {
+		return err
+	}
+	if err := validateNewAttachment(attachment); err != nil {
+		return err
+	}
+
+	return s.changeAttachments(ctx, id, AttachmentReplaceActionType, "ReplaceAttachment", func(attachments []AssetAttachment) ([]AssetAttachment, error) {
+		if err := checkAttachmentIndex(attachments, index, currentHash); err != nil {
+			return nil, err
+		}
+		attachments[index] = attachment
+		return attachments, nil
+	})
+}
+
+// RemoveAttachment deletes the attachment at index; currentHash guards it as in ReplaceAttachment.
+// The file itself stays in storage and in the asset's history.
+func (s *SmartContract) RemoveAttachment(ctx contractapi.TransactionContextInterface, id string, index int, currentHash string) error {
+	return s.changeAttachments(ctx, id, AttachmentRemoveActionType, "RemoveAttachment", func(attachments []AssetAttachment) ([]AssetAttachment, error) {
+		if err := checkAttachmentIndex(attachments, index, currentHash); err != nil {
+			return nil, err
+		}
+		return append(attachments[:index], attachments[index+1:]...), nil
+	})
+}
+
+// changeAttachments applies change to the attachments of an ACTIVE asset owned by the caller and
+// records it under action, emitting eventName. The details of a PRIVATE asset are edited in the
+// owner's collection and sealed again with their previous salt.
+func (s *SmartContract) changeAttachments(ctx contractapi.TransactionContextInterface, id string, action string, eventName string,
+	change func([]AssetAttachment) ([]AssetAttachment, error)) error {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	clientFullID, err := s.getClientFullIdentifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	if value.Asset.OwnerID != clientFullID {
+		return newError(PermissionDenied, "only the owner can change asset attachments")
+	}
+	if err := requireActive(value.Asset); err != nil {
+		return err
+	}
+
+	private := value.Asset.PrivateDataHash != EmptyTxt
+	salt := EmptyTxt
+	if private {
+		details, err := restorePrivateDetails(ctx, &value.Asset)
+		if err != nil {
+			return err
+		}
+		salt = details.Salt
+	}
+
+	attachments, err := change(append([]AssetAttachment(nil), value.Asset.Attachments...))
+	if err != nil {
+		return err
+	}
+	value.Asset.Attachments = attachments
+	if len(attachments) == 0 {
+		value.Asset.Attachments = nil
+	}
+
+	if private {
+		if err := makeAssetPrivate(ctx, &value.Asset, salt); err != nil {
+			return err
+		}
+	}
+
+	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
+	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
+
+	value.Audit = AuditMetadata{
+		Action:    action,
+		Actor:     clientFullID,
+		Timestamp: now,
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, valueJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+
+	return putAssetState(ctx, id, valueJSON)
+}
+
+func checkAttachmentIndex(attachments []AssetAttachment, index int, currentHash string) error {
+	if index < 0 || index >= len(attachments) {
+		return newError(InvalidArgument, "attachment index %d out of range (asset has %d)", index, len(attachments))
+	}
+	if attachments[index].FileHash != currentHash {
+		return newError(Conflict, "attachment %d is no longer the file with hash %s", index, currentHash)
+	}
+	return nil
+}
+
+func validateNewAttachment(attachment AssetAttachment) error {
+	if attachment == (AssetAttachment{}) {
+		return newError(InvalidArgument, "attachment must not be empty")
+	}
+	return validateAttachment(attachment)
+}
+
+// validateAttachment requires an attachment to name a file with its SHA-256
+func validateAttachment(attachment AssetAttachment) error {
+	if attachment.FileName == EmptyTxt {
+		return newError(InvalidArgument, "invalid attachment.file_name: must not be empty")
+	}
+	if attachment.FileSize < 0 {
+		return newError(InvalidArgument, "invalid attachment.file_size: must not be negative")
+	}
+	if hash, err := hex.DecodeString(attachment.FileHash); err != nil || len(hash) != 32 {
+		return newError(InvalidArgument, "invalid attachment.file_hash: must be a hex SHA-256 digest")
+	}
+	for _, field := range []struct{ name, value string }{
+		{"attachment.file_name", attachment.FileName},
+		{"attachment.ipfs_cid", attachment.IpfsCID},
+		{"attachment.storage_path", attachment.StoragePath},
+		{"attachment.storage_type", attachment.StorageType},
+	} {
+		if err := checkLength(field.name, field.value, MaxReferenceLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
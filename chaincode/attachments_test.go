@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func attachmentJSON(name string, hash string) string {
+	data, _ := json.Marshal(AssetAttachment{FileName: name, FileSize: 1, FileHash: hash, StorageType: "minio"})
+	return string(data)
+}
+
+func attachmentNames(attachments []AssetAttachment) string {
+	var names []string
+	for _, attachment := range attachments {
+		names = append(names, attachment.FileName)
+	}
+	return strings.Join(names, ",")
+}
+
+var (
+	certHash      = strings.Repeat("a", 64)
+	photoHash     = strings.Repeat("b", 64)
+	appraisalHash = strings.Repeat("c", 64)
+)
+
+func TestAttachmentLifecycle(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1") // deed.pdf, hash "filehash"
+
+	if err := l.contract.AddAttachment(l.as("Org1MSP", "alice"), "a1", attachmentJSON("cert.pdf", certHash)); err != nil {
+		t.Fatalf("AddAttachment: %v", err)
+	}
+	if err := l.contract.AddAttachment(l.as("Org1MSP", "alice"), "a1", attachmentJSON("photo.jpg", photoHash)); err != nil {
+		t.Fatalf("AddAttachment: %v", err)
+	}
+	if names := attachmentNames(storedValue(t, l, "a1").Asset.Attachments); names != "deed.pdf,cert.pdf,photo.jpg" {
+		t.Fatalf("attachments = %s", names)
+	}
+	if event := l.stub.lastEvent(); event.Name != "AddAttachment" {
+		t.Fatalf("event = %q, want AddAttachment", event.Name)
+	}
+
+	if err := l.contract.ReplaceAttachment(l.as("Org1MSP", "alice"), "a1", 1, certHash, attachmentJSON("appraisal.pdf", appraisalHash)); err != nil {
+		t.Fatalf("ReplaceAttachment: %v", err)
+	}
+	if err := l.contract.RemoveAttachment(l.as("Org1MSP", "alice"), "a1", 0, "filehash"); err != nil {
+		t.Fatalf("RemoveAttachment: %v", err)
+	}
+	value := storedValue(t, l, "a1")
+	if names := attachmentNames(value.Asset.Attachments); names != "appraisal.pdf,photo.jpg" {
+		t.Fatalf("attachments = %s", names)
+	}
+	if value.Audit.Action != AttachmentRemoveActionType || value.Audit.Actor != alice {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+
+	// Every change is its own history entry
+	records, err := l.contract.GetAssetHistory(l.as("Org1MSP", "alice"), "a1")
+	if err != nil {
+		t.Fatalf("GetAssetHistory: %v", err)
+	}
+	var actions []string
+	for _, record := range records {
+		actions = append(actions, record.ActionType)
+	}
+	want := strings.Join([]string{CreateActionType, AttachmentAddActionType, AttachmentAddActionType, AttachmentReplaceActionType, AttachmentRemoveActionType}, ",")
+	if got := strings.Join(actions, ","); got != want {
+		t.Fatalf("history actions = %s, want %s", got, want)
+	}
+}
+
+func TestAttachmentValidation(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	expectCode(t, l.contract.AddAttachment(l.as("Org2MSP", "bob"), "a1", attachmentJSON("cert.pdf", certHash)), PermissionDenied)
+	expectCode(t, l.contract.AddAttachment(l.as("Org1MSP", "alice"), "a1", `{}`), InvalidArgument)
+	expectCode(t, l.contract.AddAttachment(l.as("Org1MSP", "alice"), "a1", attachmentJSON("cert.pdf", "nothex")), InvalidArgument)
+	expectCode(t, l.contract.RemoveAttachment(l.as("Org1MSP", "alice"), "a1", 3, certHash), InvalidArgument)
+
+	// A stale index no longer pointing at the expected file is a conflict
+	err := l.contract.ReplaceAttachment(l.as("Org1MSP", "alice"), "a1", 0, certHash, attachmentJSON("cert.pdf", certHash))
+	expectCode(t, err, Conflict)
+
+	for i := 1; i < MaxAttachments; i++ {
+		if err := l.contract.AddAttachment(l.as("Org1MSP", "alice"), "a1", attachmentJSON("page.jpg", photoHash)); err != nil {
+			t.Fatalf("AddAttachment %d: %v", i, err)
+		}
+	}
+	err = l.contract.AddAttachment(l.as("Org1MSP", "alice"), "a1", attachmentJSON("page.jpg", photoHash))
+	expectError(t, err, "maximum")
+}
+
+func TestPrivateAssetAttachments(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1") // deed.pdf
+
+	ctx := l.as("Org1MSP", "alice")
+	l.stub.transient = map[string][]byte{TransientAttachmentKey: []byte(attachmentJSON("cert.pdf", certHash))}
+	if err := l.contract.AddAttachment(ctx, "p1", ""); err != nil {
+		t.Fatalf("AddAttachment: %v", err)
+	}
+
+	asset := storedValue(t, l, "p1").Asset
+	if len(asset.Attachments) != 0 {
+		t.Fatalf("private attachments leaked into world state: %+v", asset.Attachments)
+	}
+	details, err := l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "alice"), "p1")
+	if err != nil {
+		t.Fatalf("ReadAssetPrivateDetails: %v", err)
+	}
+	if names := attachmentNames(details.Attachments); names != "deed.pdf,cert.pdf" || details.Salt != testSalt {
+		t.Fatalf("unexpected private details: %+v", details)
+	}
+}
+
+func TestLegacySingleAttachmentIsRead(t *testing.T) {
+	l := newTestLedger()
+	l.stub.state[stateKey("old")] = []byte(`{"asset":{"ID":"old","ownerId":"Org1MSP::alice","status":"ACTIVE","view":"PUBLIC","attachment":{"file_name":"deed.pdf","file_hash":"` + certHash + `"}}}`)
+	l.stub.state[stateKey("none")] = []byte(`{"asset":{"ID":"none","ownerId":"Org1MSP::alice","status":"ACTIVE","view":"PUBLIC","attachment":{"file_name":"","file_size":0}}}`)
+
+	value, err := l.contract.ReadAsset(l.as("Org1MSP", "alice"), "old")
+	if err != nil {
+		t.Fatalf("ReadAsset: %v", err)
+	}
+	if names := attachmentNames(value.Asset.Attachments); names != "deed.pdf" {
+		t.Fatalf("legacy attachment not converted: %+v", value.Asset)
+	}
+	value, err = l.contract.ReadAsset(l.as("Org1MSP", "alice"), "none")
+	if err != nil || len(value.Asset.Attachments) != 0 {
+		t.Fatalf("empty legacy attachment = %+v, %v", value, err)
+	}
+
+	// Private details in the owner's collection are converted the same way
+	detailsJSON := []byte(`{"ID":"hidden","name":"n","salt":"` + testSalt + `","attachment":{"file_name":"deed.pdf","file_hash":"` + certHash + `"}}`)
+	l.stub.private[implicitCollection("Org1MSP")] = map[string][]byte{"hidden": detailsJSON}
+	l.stub.state[stateKey("hidden")] = []byte(`{"asset":{"ID":"hidden","ownerId":"Org1MSP::alice","status":"ACTIVE","view":"PRIVATE","privateDataHash":"` + hashBytes(detailsJSON) + `"}}`)
+	details, err := l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "alice"), "hidden")
+	if err != nil {
+		t.Fatalf("ReadAssetPrivateDetails: %v", err)
+	}
+	if names := attachmentNames(details.Attachments); names != "deed.pdf" {
+		t.Fatalf("legacy private attachment not converted: %+v", details)
+	}
+
+	// The next write stores the list
+	if err := l.contract.RemoveAttachment(l.as("Org1MSP", "alice"), "old", 0, certHash); err != nil {
+		t.Fatalf("RemoveAttachment: %v", err)
+	}
+	if stored := string(l.stub.state[stateKey("old")]); strings.Contains(stored, `"attachment"`) {
+		t.Fatalf("legacy field written back: %s", stored)
+	}
+}
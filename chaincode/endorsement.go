@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// mspFromFullID extracts the MSP ID from an "MSPID::username" identifier
+func mspFromFullID(fullID string) string {
+	mspid, _, found := strings.Cut(fullID, "::")
+	if !found {
+		return EmptyTxt
+	}
+	return mspid
+}
+
+// setAssetEndorsers sets a key-level endorsement policy on the asset's key requiring peers of every
+// MSP that owns (or is about to own) the asset. Owners without an MSP prefix (legacy data) are skipped.
+func setAssetEndorsers(ctx contractapi.TransactionContextInterface, id string, ownerIDs ...string) error {
+	var orgs []string
+	seen := map[string]bool{}
+	for _, ownerID := range ownerIDs {
+		mspid := mspFromFullID(ownerID)
+		if mspid == EmptyTxt || seen[mspid] {
+			continue
+		}
+		seen[mspid] = true
+		orgs = append(orgs, mspid)
+	}
+	if len(orgs) == 0 {
+		return nil
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return err
+	}
+	if err := endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgs...); err != nil {
+		return fmt.Errorf("failed to add orgs %v to endorsement policy: %v", orgs, err)
+	}
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy bytes: %v", err)
+	}
+
+	key, err := assetKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return fmt.Errorf("failed to set validation parameter on %s: %v", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+)
+
+func assetEndorsers(t *testing.T, l *testLedger, id string) string {
+	t.Helper()
+	policy := l.stub.validation[stateKey(id)]
+	if policy == nil {
+		return ""
+	}
+	ep, err := statebased.NewStateEP(policy)
+	if err != nil {
+		t.Fatalf("parse endorsement policy for %s: %v", id, err)
+	}
+	orgs := ep.ListOrgs()
+	sort.Strings(orgs)
+	return strings.Join(orgs, ",")
+}
+
+func TestMspFromFullID(t *testing.T) {
+	for fullID, want := range map[string]string{
+		"Org1MSP::alice": "Org1MSP",
+		"Org2MSP::":      "Org2MSP",
+		"legacy-owner":   "",
+		"":               "",
+	} {
+		if got := mspFromFullID(fullID); got != want {
+			t.Fatalf("mspFromFullID(%q) = %q, want %q", fullID, got, want)
+		}
+	}
+}
+
+func TestAssetEndorsersFollowTransfer(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	createPublicAsset(t, l, "a2")
+
+	if got := assetEndorsers(t, l, "a1"); got != "Org1MSP" {
+		t.Fatalf("endorsers after create = %q, want Org1MSP", got)
+	}
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if got := assetEndorsers(t, l, "a1"); got != "Org1MSP,Org2MSP" {
+		t.Fatalf("endorsers while pending = %q, want Org1MSP,Org2MSP", got)
+	}
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a1"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+	if got := assetEndorsers(t, l, "a1"); got != "Org2MSP" {
+		t.Fatalf("endorsers after accept = %q, want Org2MSP", got)
+	}
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a2", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if err := l.contract.CancelTransfer(l.as("Org1MSP", "alice"), "a2"); err != nil {
+		t.Fatalf("CancelTransfer: %v", err)
+	}
+	if got := assetEndorsers(t, l, "a2"); got != "Org1MSP" {
+		t.Fatalf("endorsers after cancel = %q, want Org1MSP", got)
+	}
+}
+
+func TestSetAssetEndorsersSkipsLegacyOwners(t *testing.T) {
+	l := newTestLedger()
+	if err := setAssetEndorsers(l.as("Org1MSP", "alice"), "legacy", "legacy-owner"); err != nil {
+		t.Fatalf("setAssetEndorsers: %v", err)
+	}
+	if _, ok := l.stub.validation[stateKey("legacy")]; ok {
+		t.Fatalf("policy set for an owner without an MSP")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies a chaincode error so that clients can tell a rejected request from a failure
+type ErrorCode string
+
+// Constants for error codes; errors without a code are internal failures
+const (
+	InvalidArgument  ErrorCode = "INVALID_ARGUMENT"
+	NotFound         ErrorCode = "NOT_FOUND"
+	PermissionDenied ErrorCode = "PERMISSION_DENIED"
+	Conflict         ErrorCode = "CONFLICT"
+	// AlreadyExists is the conflict of creating an ID that is taken, e.g. a retried CreateAsset
+	AlreadyExists ErrorCode = "ALREADY_EXISTS"
+)
+
+// ContractError is an error the caller can act on. Its Error() is a JSON object
+// {"code": ..., "message": ...}, which is what the peer puts in the endorsement response.
+type ContractError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func (e *ContractError) Error() string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Keep "->" and friends readable in peer logs
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(e); err != nil {
+		return e.Message
+	}
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
+
+func newError(code ErrorCode, format string, args ...interface{}) error {
+	return &ContractError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// prefixError prepends context to an error's message, keeping its code
+func prefixError(err error, format string, args ...interface{}) error {
+	var contractErr *ContractError
+	if errors.As(err, &contractErr) {
+		return newError(contractErr.Code, "%s: %s", fmt.Sprintf(format, args...), contractErr.Message)
+	}
+	return fmt.Errorf("%s: %v", fmt.Sprintf(format, args...), err)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func expectCode(t *testing.T, err error, code ErrorCode) {
+	t.Helper()
+	var contractErr *ContractError
+	if !errors.As(err, &contractErr) {
+		t.Fatalf("expected %s error, got %v", code, err)
+	}
+	if contractErr.Code != code {
+		t.Fatalf("expected %s error, got %s: %s", code, contractErr.Code, contractErr.Message)
+	}
+}
+
+func TestContractErrorIsJSON(t *testing.T) {
+	err := newError(Conflict, "illegal status transition %s -> %s", FrozenStatus, PendingTransferStatus)
+
+	var decoded ContractError
+	if jsonErr := json.Unmarshal([]byte(err.Error()), &decoded); jsonErr != nil {
+		t.Fatalf("Error() is not JSON: %q", err.Error())
+	}
+	if decoded.Code != Conflict || decoded.Message != "illegal status transition FROZEN -> PENDING_TRANSFER" {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+	if err.Error() != `{"code":"CONFLICT","message":"illegal status transition FROZEN -> PENDING_TRANSFER"}` {
+		t.Fatalf("Error() = %s", err.Error())
+	}
+}
+
+func TestPrefixErrorKeepsCode(t *testing.T) {
+	err := prefixError(newError(NotFound, "asset a1 does not exist"), "lookup")
+	expectCode(t, err, NotFound)
+	expectError(t, err, "lookup: asset a1 does not exist")
+
+	plain := prefixError(errors.New("boom"), "lookup")
+	var contractErr *ContractError
+	if errors.As(plain, &contractErr) {
+		t.Fatalf("plain error gained a code: %v", plain)
+	}
+	if plain.Error() != "lookup: boom" {
+		t.Fatalf("plain = %q", plain.Error())
+	}
+}
+
+func TestContractErrorCodes(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "asset1")
+
+	_, err := l.contract.ReadAsset(l.as("Org1MSP", "alice"), "missing")
+	expectCode(t, err, NotFound)
+
+	_, err = l.contract.CreateAsset(l.as("Org1MSP", "alice"), "asset1", "Painting", "", "", "", PublicView,
+		"", 0, "", "", "", "")
+	expectCode(t, err, AlreadyExists)
+
+	err = l.contract.ProposeTransfer(l.as("Org2MSP", "bob"), "asset1", carol, "")
+	expectCode(t, err, PermissionDenied)
+
+	err = l.contract.UpdateAssetView(l.as("Org1MSP", "alice"), "asset1", "SECRET")
+	expectCode(t, err, InvalidArgument)
+
+	err = l.contract.UpdateAssetStatus(l.as("Org1MSP", "alice"), "asset1", FrozenStatus)
+	expectCode(t, err, PermissionDenied)
+	expectError(t, err, "administrative access required: access denied")
+
+	err = l.contract.UpdateAssetStatus(l.asAdmin(), "asset1", PendingTransferStatus)
+	expectCode(t, err, InvalidArgument)
+
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "asset1", DeletedStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	err = l.contract.UpdateAssetStatus(l.asAdmin(), "asset1", ActiveStatus)
+	expectCode(t, err, Conflict)
+	expectError(t, err, "asset asset1: illegal status transition DELETED -> ACTIVE")
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeStub is an in-memory shim.ChaincodeStubInterface covering the calls the contract makes.
+// Unimplemented methods fall through to the nil embedded interface and panic, so a test fails
+// loudly if the contract starts depending on something the fake doesn't model.
+type fakeStub struct {
+	shim.ChaincodeStubInterface
+
+	txID        string
+	txTimestamp time.Time
+	transient   map[string][]byte
+
+	state      map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	private    map[string]map[string][]byte
+	validation map[string][]byte
+	events     []fakeEvent
+}
+
+type fakeEvent struct {
+	Name    string
+	Payload []byte
+}
+
+func newFakeStub() *fakeStub {
+	return &fakeStub{
+		txTimestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		state:       map[string][]byte{},
+		history:     map[string][]*queryresult.KeyModification{},
+		private:     map[string]map[string][]byte{},
+		validation:  map[string][]byte{},
+	}
+}
+
+// startTx begins a new transaction: a fresh tx ID, a clock one minute later and no transient data
+func (s *fakeStub) startTx(txID string) {
+	s.txID = txID
+	s.txTimestamp = s.txTimestamp.Add(time.Minute)
+	s.transient = nil
+}
+
+func (s *fakeStub) lastEvent() fakeEvent {
+	if len(s.events) == 0 {
+		return fakeEvent{}
+	}
+	return s.events[len(s.events)-1]
+}
+
+func (s *fakeStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *fakeStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.txTimestamp), nil
+}
+
+func (s *fakeStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *fakeStub) SetEvent(name string, payload []byte) error {
+	s.events = append(s.events, fakeEvent{Name: name, Payload: payload})
+	return nil
+}
+
+func (s *fakeStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *fakeStub) PutState(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	s.state[key] = value
+	s.recordHistory(key, value, false)
+	return nil
+}
+
+func (s *fakeStub) DelState(key string) error {
+	delete(s.state, key)
+	s.recordHistory(key, nil, true)
+	return nil
+}
+
+func (s *fakeStub) recordHistory(key string, value []byte, isDelete bool) {
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{
+		TxId:      s.txID,
+		Value:     value,
+		Timestamp: timestamppb.New(s.txTimestamp),
+		IsDelete:  isDelete,
+	})
+}
+
+func (s *fakeStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &fakeHistoryIterator{records: s.history[key]}, nil
+}
+
+func (s *fakeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *fakeStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	parts := strings.Split(strings.TrimPrefix(compositeKey, "\x00"), "\x00")
+	if len(parts) < 2 {
+		return "", nil, fmt.Errorf("invalid composite key %q", compositeKey)
+	}
+	return parts[0], parts[1 : len(parts)-1], nil
+}
+
+// simpleKeys returns the sorted non-composite keys in [startKey, endKey)
+func (s *fakeStub) simpleKeys(startKey, endKey string) []string {
+	var keys []string
+	for key := range s.state {
+		if strings.HasPrefix(key, "\x00") {
+			continue
+		}
+		if key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// allKeys returns every sorted key from startKey on, composite keys included
+func (s *fakeStub) allKeys(startKey string) []string {
+	var keys []string
+	for key := range s.state {
+		if key >= startKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *fakeStub) kvs(keys []string) []*queryresult.KV {
+	var results []*queryresult.KV
+	for _, key := range keys {
+		results = append(results, &queryresult.KV{Key: key, Value: s.state[key]})
+	}
+	return results
+}
+
+func (s *fakeStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return &fakeStateIterator{results: s.kvs(s.simpleKeys(startKey, endKey))}, nil
+}
+
+func (s *fakeStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	keys, err := s.compositeKeys(objectType, attributes, "")
+	if err != nil {
+		return nil, err
+	}
+	return &fakeStateIterator{results: s.kvs(keys)}, nil
+}
+
+func (s *fakeStub) GetStateByPartialCompositeKeyWithPagination(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	keys, err := s.compositeKeys(objectType, attributes, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.page(keys, pageSize)
+}
+
+// compositeKeys returns the sorted composite keys with the given prefix, starting at bookmark
+func (s *fakeStub) compositeKeys(objectType string, attributes []string, bookmark string) ([]string, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key := range s.state {
+		if strings.HasPrefix(key, prefix) && key >= bookmark {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *fakeStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	return s.page(s.simpleKeys(startKey, endKey), pageSize)
+}
+
+// GetQueryResultWithPagination supports the subset of Mango the contract uses: equality on
+// dotted field paths and {"$exists": bool}
+func (s *fakeStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, nil, fmt.Errorf("invalid query: %v", err)
+	}
+
+	// CouchDB stores composite keys as documents too, so rich queries see every key
+	var keys []string
+	for _, key := range s.allKeys(bookmark) {
+		var doc map[string]interface{}
+		if json.Unmarshal(s.state[key], &doc) != nil {
+			continue
+		}
+		if matchesSelector(doc, parsed.Selector) {
+			keys = append(keys, key)
+		}
+	}
+	return s.page(keys, pageSize)
+}
+
+func (s *fakeStub) page(keys []string, pageSize int32) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	bookmark := ""
+	if int32(len(keys)) > pageSize {
+		bookmark = keys[pageSize]
+		keys = keys[:pageSize]
+	}
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(keys)), Bookmark: bookmark}
+	return &fakeStateIterator{results: s.kvs(keys)}, metadata, nil
+}
+
+func matchesSelector(doc map[string]interface{}, selector map[string]interface{}) bool {
+	for path, want := range selector {
+		got, found := lookupPath(doc, path)
+		if cond, ok := want.(map[string]interface{}); ok {
+			if exists, ok := cond["$exists"].(bool); ok && exists != found {
+				return false
+			}
+			continue
+		}
+		if !found || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+func lookupPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (s *fakeStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.private[collection][key], nil
+}
+
+func (s *fakeStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, ok := s.private[collection][key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *fakeStub) PutPrivateData(collection, key string, value []byte) error {
+	if s.private[collection] == nil {
+		s.private[collection] = map[string][]byte{}
+	}
+	s.private[collection][key] = value
+	return nil
+}
+
+func (s *fakeStub) DelPrivateData(collection, key string) error {
+	delete(s.private[collection], key)
+	return nil
+}
+
+func (s *fakeStub) SetStateValidationParameter(key string, ep []byte) error {
+	s.validation[key] = ep
+	return nil
+}
+
+func (s *fakeStub) GetStateValidationParameter(key string) ([]byte, error) {
+	return s.validation[key], nil
+}
+
+type fakeStateIterator struct {
+	results []*queryresult.KV
+	index   int
+}
+
+func (it *fakeStateIterator) HasNext() bool {
+	return it.index < len(it.results)
+}
+
+func (it *fakeStateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("iterator exhausted")
+	}
+	it.index++
+	return it.results[it.index-1], nil
+}
+
+func (it *fakeStateIterator) Close() error {
+	return nil
+}
+
+type fakeHistoryIterator struct {
+	records []*queryresult.KeyModification
+	index   int
+}
+
+func (it *fakeHistoryIterator) HasNext() bool {
+	return it.index < len(it.records)
+}
+
+func (it *fakeHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("iterator exhausted")
+	}
+	it.index++
+	return it.records[it.index-1], nil
+}
+
+func (it *fakeHistoryIterator) Close() error {
+	return nil
+}
+
+// fakeIdentity is a configurable cid.ClientIdentity: an MSP ID, certificate attributes
+// (including hf.EnrollmentID) and a certificate whose CN is used as the fallback username
+type fakeIdentity struct {
+	mspid      string
+	commonName string
+	attrs      map[string]string
+}
+
+func (id *fakeIdentity) GetID() (string, error) {
+	return fmt.Sprintf("x509::CN=%s::%s", id.commonName, id.mspid), nil
+}
+
+func (id *fakeIdentity) GetMSPID() (string, error) {
+	return id.mspid, nil
+}
+
+func (id *fakeIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := id.attrs[attrName]
+	return value, found, nil
+}
+
+func (id *fakeIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	value, found := id.attrs[attrName]
+	if !found {
+		return fmt.Errorf("attribute '%s' was not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("attribute '%s' equals '%s', not '%s'", attrName, value, attrValue)
+	}
+	return nil
+}
+
+func (id *fakeIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: id.commonName}}, nil
+}
+
+// testLedger wires the contract to one fake stub so that calls by different identities share state
+type testLedger struct {
+	stub     *fakeStub
+	contract *SmartContract
+	txCount  int
+}
+
+func newTestLedger() *testLedger {
+	return &testLedger{stub: newFakeStub(), contract: &SmartContract{}}
+}
+
+// as starts a new transaction submitted by username@mspid (enrolled with hf.EnrollmentID)
+func (l *testLedger) as(mspid, username string, attrs ...string) contractapi.TransactionContextInterface {
+	identity := &fakeIdentity{mspid: mspid, commonName: username, attrs: map[string]string{"hf.EnrollmentID": username}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		identity.attrs[attrs[i]] = attrs[i+1]
+	}
+	return l.withIdentity(identity)
+}
+
+// asAdmin starts a new transaction submitted by an identity holding the admin role attribute
+func (l *testLedger) asAdmin() contractapi.TransactionContextInterface {
+	return l.as("Org1MSP", "admin", RoleAttribute, AdminRole)
+}
+
+func (l *testLedger) withIdentity(identity *fakeIdentity) contractapi.TransactionContextInterface {
+	l.txCount++
+	l.stub.startTx(fmt.Sprintf("tx%d", l.txCount))
+
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(l.stub)
+	ctx.SetClientIdentity(identity)
+	return ctx
+}
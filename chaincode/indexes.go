@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Secondary indexes over assets. An entry is the composite key <index>~<value>~<id> holding a
+// placeholder value; the asset itself is read from its own key.
+const (
+	OwnerIndex  = "owner~asset"
+	StatusIndex = "status~asset"
+)
+
+// indexPlaceholder is stored under index keys, since a nil value would delete the key
+var indexPlaceholder = []byte{0x00}
+
+// assetIndexValues returns the value each index files the asset under
+func assetIndexValues(asset Asset) map[string]string {
+	return map[string]string{
+		OwnerIndex:  asset.OwnerID,
+		StatusIndex: asset.Status,
+	}
+}
+
+// legacyAttachment is the single attachment stored before attachments became a list
+type legacyAttachment struct {
+	Attachment *AssetAttachment `json:"attachment"`
+}
+
+// upgrade makes a legacy attachment, if any, the first entry of an empty list
+func (l legacyAttachment) upgrade(attachments *[]AssetAttachment) {
+	if len(*attachments) == 0 && l.Attachment != nil && *l.Attachment != (AssetAttachment{}) {
+		*attachments = []AssetAttachment{*l.Attachment}
+	}
+}
+
+// storedLedgerValue decodes a world state value, wrapping legacy flat assets and converting a
+// legacy single attachment
+func storedLedgerValue(valueJSON []byte) (LedgerValue, error) {
+	var value LedgerValue
+	var temp map[string]interface{}
+	if err := json.Unmarshal(valueJSON, &temp); err != nil {
+		return value, fmt.Errorf("failed to decode asset: %v", err)
+	}
+
+	if _, ok := temp["asset"]; ok || temp["Asset"] != nil {
+		var legacy struct {
+			Asset legacyAttachment `json:"asset"`
+		}
+		if err := json.Unmarshal(valueJSON, &value); err != nil {
+			return value, fmt.Errorf("failed to decode asset: %v", err)
+		}
+		if err := json.Unmarshal(valueJSON, &legacy); err != nil {
+			return value, fmt.Errorf("failed to decode asset: %v", err)
+		}
+		legacy.Asset.upgrade(&value.Asset.Attachments)
+		return value, nil
+	}
+
+	var asset Asset
+	var legacy legacyAttachment
+	if err := json.Unmarshal(valueJSON, &asset); err != nil {
+		return value, fmt.Errorf("failed to decode legacy asset: %v", err)
+	}
+	if err := json.Unmarshal(valueJSON, &legacy); err != nil {
+		return value, fmt.Errorf("failed to decode legacy asset: %v", err)
+	}
+	legacy.upgrade(&asset.Attachments)
+	value.Asset = asset
+	value.Audit = AuditMetadata{Action: "LEGACY", Actor: "Unknown"}
+	return value, nil
+}
+
+// storedPrivateDetails decodes private details from an org collection, converting a legacy
+// single attachment like storedLedgerValue
+func storedPrivateDetails(detailsJSON []byte) (*AssetPrivateDetails, error) {
+	var details AssetPrivateDetails
+	var legacy legacyAttachment
+	if err := json.Unmarshal(detailsJSON, &details); err != nil {
+		return nil, fmt.Errorf("failed to decode private details: %v", err)
+	}
+	if err := json.Unmarshal(detailsJSON, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to decode private details: %v", err)
+	}
+	legacy.upgrade(&details.Attachments)
+	return &details, nil
+}
+
+// updateAssetIndexes moves the index entries of asset id from the previous value (nil for a new
+// asset) to the current one. Current entries are written even when unchanged, so an asset
+// written before an index existed is filed on its next update.
+func updateAssetIndexes(ctx contractapi.TransactionContextInterface, id string, previousJSON []byte, current Asset) error {
+	currentValues := assetIndexValues(current)
+	if previousJSON != nil {
+		previous, err := storedLedgerValue(previousJSON)
+		if err != nil {
+			return err
+		}
+		for index, value := range assetIndexValues(previous.Asset) {
+			if value == EmptyTxt || value == currentValues[index] {
+				continue
+			}
+			key, err := ctx.GetStub().CreateCompositeKey(index, []string{value, id})
+			if err != nil {
+				return fmt.Errorf("failed to create %s key: %v", index, err)
+			}
+			if err := ctx.GetStub().DelState(key); err != nil {
+				return fmt.Errorf("failed to delete %s entry: %v", index, err)
+			}
+		}
+	}
+
+	for index, value := range currentValues {
+		// Legacy assets may lack an owner or status
+		if value == EmptyTxt {
+			continue
+		}
+		key, err := ctx.GetStub().CreateCompositeKey(index, []string{value, id})
+		if err != nil {
+			return newError(InvalidArgument, "invalid %s value %q: %v", index, value, err)
+		}
+		if err := ctx.GetStub().PutState(key, indexPlaceholder); err != nil {
+			return fmt.Errorf("failed to put %s entry: %v", index, err)
+		}
+	}
+	return nil
+}
+
+// GetAssetsByOwner returns one page of the assets owned by ownerID ("MSPID::username"), read
+// through the owner~asset index
+func (s *SmartContract) GetAssetsByOwner(ctx contractapi.TransactionContextInterface, ownerID string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	return getAssetsByIndex(ctx, OwnerIndex, ownerID, pageSize, bookmark)
+}
+
+// GetAssetsByStatus returns one page of the assets in the given status, read through the
+// status~asset index
+func (s *SmartContract) GetAssetsByStatus(ctx contractapi.TransactionContextInterface, status string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	return getAssetsByIndex(ctx, StatusIndex, status, pageSize, bookmark)
+}
+
+func getAssetsByIndex(ctx contractapi.TransactionContextInterface, index string, attribute string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	if attribute == EmptyTxt {
+		return nil, newError(InvalidArgument, "%s lookup value must not be empty", index)
+	}
+	if pageSize <= 0 {
+		return nil, newError(InvalidArgument, "page size must be greater than zero")
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(index, []string{attribute}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	records := []*LedgerValue{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) != 2 {
+			return nil, fmt.Errorf("malformed %s key %q", index, queryResponse.Key)
+		}
+
+		valueJSON, err := getAssetState(ctx, attributes[1])
+		if err != nil {
+			return nil, err
+		}
+		// An index entry outliving its asset is skipped rather than failing the page
+		if valueJSON == nil {
+			continue
+		}
+		value, err := storedLedgerValue(valueJSON)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &value)
+	}
+
+	return &PaginatedQueryResult{
+		Records:             records,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// indexedIDs lists the asset IDs filed under value in the given index
+func indexedIDs(t *testing.T, l *testLedger, index string, value string) string {
+	t.Helper()
+	prefix, err := shim.CreateCompositeKey(index, []string{value})
+	if err != nil {
+		t.Fatalf("index prefix: %v", err)
+	}
+	var ids []string
+	for key := range l.stub.state {
+		if strings.HasPrefix(key, prefix) {
+			_, attributes, _ := l.stub.SplitCompositeKey(key)
+			ids = append(ids, attributes[1])
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func TestIndexesFollowOwnerAndStatus(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	createPublicAsset(t, l, "a2")
+
+	if got := indexedIDs(t, l, OwnerIndex, alice); got != "a1,a2" {
+		t.Fatalf("owner index for alice = %q, want a1,a2", got)
+	}
+	if got := indexedIDs(t, l, StatusIndex, ActiveStatus); got != "a1,a2" {
+		t.Fatalf("status index for ACTIVE = %q, want a1,a2", got)
+	}
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if got := indexedIDs(t, l, StatusIndex, PendingTransferStatus); got != "a1" {
+		t.Fatalf("status index for PENDING_TRANSFER = %q, want a1", got)
+	}
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a1"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+
+	for _, c := range []struct{ index, value, want string }{
+		{OwnerIndex, alice, "a2"},
+		{OwnerIndex, bob, "a1"},
+		{StatusIndex, ActiveStatus, "a1,a2"},
+		{StatusIndex, PendingTransferStatus, ""},
+	} {
+		if got := indexedIDs(t, l, c.index, c.value); got != c.want {
+			t.Fatalf("%s for %s = %q, want %q", c.index, c.value, got, c.want)
+		}
+	}
+
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a2", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	if got := indexedIDs(t, l, StatusIndex, FrozenStatus); got != "a2" {
+		t.Fatalf("status index for FROZEN = %q, want a2", got)
+	}
+	if got := indexedIDs(t, l, StatusIndex, ActiveStatus); got != "a1" {
+		t.Fatalf("status index for ACTIVE = %q, want a1", got)
+	}
+}
+
+func TestGetAssetsByOwnerAndStatus(t *testing.T) {
+	l := newTestLedger()
+	for _, id := range []string{"a1", "a2", "a3"} {
+		createPublicAsset(t, l, id)
+	}
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a2", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+
+	_, err := l.contract.GetAssetsByOwner(l.as("Org2MSP", "bob"), "", 10, "")
+	expectCode(t, err, InvalidArgument)
+	_, err = l.contract.GetAssetsByStatus(l.as("Org2MSP", "bob"), ActiveStatus, 0, "")
+	expectCode(t, err, InvalidArgument)
+
+	page, err := l.contract.GetAssetsByOwner(l.as("Org2MSP", "bob"), alice, 2, "")
+	if err != nil {
+		t.Fatalf("GetAssetsByOwner: %v", err)
+	}
+	if len(page.Records) != 2 || page.Records[0].Asset.ID != "a1" || page.Bookmark == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page, err = l.contract.GetAssetsByOwner(l.as("Org2MSP", "bob"), alice, 2, page.Bookmark)
+	if err != nil {
+		t.Fatalf("GetAssetsByOwner: %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Asset.ID != "a3" || page.Bookmark != "" {
+		t.Fatalf("unexpected last page: %+v", page)
+	}
+
+	page, err = l.contract.GetAssetsByStatus(l.as("Org2MSP", "bob"), FrozenStatus, 10, "")
+	if err != nil {
+		t.Fatalf("GetAssetsByStatus: %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Asset.ID != "a2" || page.Records[0].Asset.Status != FrozenStatus {
+		t.Fatalf("unexpected FROZEN page: %+v", page)
+	}
+
+	page, err = l.contract.GetAssetsByOwner(l.as("Org2MSP", "bob"), bob, 10, "")
+	if err != nil || len(page.Records) != 0 {
+		t.Fatalf("GetAssetsByOwner(bob) = %+v, %v", page, err)
+	}
+}
+
+func TestIndexesCoverMigratedAssets(t *testing.T) {
+	l := newTestLedger()
+	l.stub.state["legacy"] = []byte(`{"ID":"legacy","ownerId":"Org1MSP::alice","status":"ACTIVE"}`)
+	if _, err := l.contract.MigrateAssetKeys(l.asAdmin(), 10); err != nil {
+		t.Fatalf("MigrateAssetKeys: %v", err)
+	}
+	if got := indexedIDs(t, l, OwnerIndex, alice); got != "legacy" {
+		t.Fatalf("owner index after migration = %q, want legacy", got)
+	}
+
+	// Assets written before the indexes existed are filed on their next update
+	l.stub.state[stateKey("old")] = []byte(`{"asset":{"ID":"old","ownerId":"Org1MSP::alice","status":"ACTIVE","view":"PUBLIC"}}`)
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "old", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	if got := indexedIDs(t, l, OwnerIndex, alice); got != "legacy,old" {
+		t.Fatalf("owner index after update = %q, want legacy,old", got)
+	}
+}
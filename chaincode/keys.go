@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AssetObjectType namespaces assets in the world state: an asset lives under the composite key
+// asset~<id>, so range queries over assets never see other kinds of keys
+const AssetObjectType = "asset"
+
+// GeneratedIDPrefix starts the IDs CreateAsset generates when the caller supplies none
+const GeneratedIDPrefix = "asset-"
+
+// KeyMigrationResult reports one MigrateAssetKeys batch
+type KeyMigrationResult struct {
+	Migrated  []string `json:"migrated"`
+	Remaining bool     `json:"remaining"`
+}
+
+// assetKey returns the world state key of an asset
+func assetKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	if id == EmptyTxt {
+		return EmptyTxt, newError(InvalidArgument, "asset ID must not be empty")
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(AssetObjectType, []string{id})
+	if err != nil {
+		return EmptyTxt, newError(InvalidArgument, "invalid asset ID %q: %v", id, err)
+	}
+	return key, nil
+}
+
+// getAssetState reads an asset from its composite key, falling back to the bare key of an asset
+// not yet moved by MigrateAssetKeys
+func getAssetState(ctx contractapi.TransactionContextInterface, id string) ([]byte, error) {
+	key, err := assetKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	valueJSON, err := ctx.GetStub().GetState(key)
+	if err != nil || valueJSON != nil {
+		return valueJSON, err
+	}
+	return ctx.GetStub().GetState(id)
+}
+
+// putAssetState writes an asset and keeps its owner~asset and status~asset entries in step. An
+// asset still under its bare key is migrated first, so it is never stored twice.
+func putAssetState(ctx contractapi.TransactionContextInterface, id string, valueJSON []byte) error {
+	key, err := assetKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read asset %s: %v", id, err)
+	}
+	if previousJSON == nil {
+		legacyJSON, err := ctx.GetStub().GetState(id)
+		if err != nil {
+			return fmt.Errorf("failed to read asset %s: %v", id, err)
+		}
+		if legacyJSON != nil {
+			if err := migrateAssetKey(ctx, id, legacyJSON); err != nil {
+				return err
+			}
+			previousJSON = legacyJSON
+		}
+	}
+	if err := ctx.GetStub().PutState(key, valueJSON); err != nil {
+		return err
+	}
+	value, err := storedLedgerValue(valueJSON)
+	if err != nil {
+		return err
+	}
+	return updateAssetIndexes(ctx, id, previousJSON, value.Asset)
+}
+
+// newAssetID derives an asset ID from the transaction ID, so every endorser generates the same
+// one and no two transactions can
+func newAssetID(ctx contractapi.TransactionContextInterface) string {
+	sum := sha256.Sum256([]byte(ctx.GetStub().GetTxID()))
+	return GeneratedIDPrefix + hex.EncodeToString(sum[:16])
+}
+
+// MigrateAssetKeys moves up to pageSize assets stored under bare keys (before assets were
+// namespaced) to their composite keys, carrying over the key-level endorsement policy. The
+// transaction must be endorsed by the owning organizations of the migrated assets. Call it
+// until Remaining is false.
+func (s *SmartContract) MigrateAssetKeys(ctx contractapi.TransactionContextInterface, pageSize int32) (*KeyMigrationResult, error) {
+	if err := requireRole(ctx, AdminRole); err != nil {
+		return nil, prefixError(err, "administrative access required")
+	}
+	if pageSize <= 0 {
+		return nil, newError(InvalidArgument, "page size must be greater than zero")
+	}
+
+	// A range query only returns simple keys, which are all legacy assets
+	resultsIterator, err := ctx.GetStub().GetStateByRange(EmptyTxt, EmptyTxt)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	result := &KeyMigrationResult{Migrated: []string{}}
+	for int32(len(result.Migrated)) < pageSize && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if err := migrateAssetKey(ctx, queryResponse.Key, queryResponse.Value); err != nil {
+			return nil, err
+		}
+		result.Migrated = append(result.Migrated, queryResponse.Key)
+	}
+	result.Remaining = resultsIterator.HasNext()
+	return result, nil
+}
+
+func migrateAssetKey(ctx contractapi.TransactionContextInterface, id string, valueJSON []byte) error {
+	key, err := assetKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read asset %s: %v", id, err)
+	}
+	if existing != nil {
+		return newError(Conflict, "asset %s exists under both its bare and its composite key", id)
+	}
+
+	value, err := storedLedgerValue(valueJSON)
+	if err != nil {
+		return prefixError(err, "failed to migrate asset %s", id)
+	}
+	if err := ctx.GetStub().PutState(key, valueJSON); err != nil {
+		return err
+	}
+	if err := updateAssetIndexes(ctx, id, nil, value.Asset); err != nil {
+		return err
+	}
+	policy, err := ctx.GetStub().GetStateValidationParameter(id)
+	if err != nil {
+		return fmt.Errorf("failed to read validation parameter of %s: %v", id, err)
+	}
+	if policy != nil {
+		if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+			return fmt.Errorf("failed to set validation parameter on %s: %v", id, err)
+		}
+	}
+	return ctx.GetStub().DelState(id)
+}
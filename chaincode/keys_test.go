@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateAssetGeneratesID(t *testing.T) {
+	l := newTestLedger()
+	ctx := l.as("Org1MSP", "alice")
+	id, err := l.contract.CreateAsset(ctx, "", "n", "d", "", "", PublicView, "", 0, "", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+	if !strings.HasPrefix(id, GeneratedIDPrefix) || id != newAssetID(ctx) {
+		t.Fatalf("generated ID = %q, want %q", id, newAssetID(ctx))
+	}
+	if value := storedValue(t, l, id); value.Asset.ID != id {
+		t.Fatalf("stored asset ID = %q, want %q", value.Asset.ID, id)
+	}
+
+	other, err := l.contract.CreateAsset(l.as("Org1MSP", "alice"), "", "n", "d", "", "", PublicView, "", 0, "", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+	if other == id {
+		t.Fatalf("two transactions generated the same ID %q", id)
+	}
+
+	given, err := l.contract.CreateAsset(l.as("Org1MSP", "alice"), "a1", "n", "d", "", "", PublicView, "", 0, "", "", "", "")
+	if err != nil || given != "a1" {
+		t.Fatalf("CreateAsset(a1) = %q, %v", given, err)
+	}
+}
+
+func TestAssetKeysAreNamespaced(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	if _, ok := l.stub.state["a1"]; ok {
+		t.Fatalf("asset stored under its bare key")
+	}
+	// Other bare keys in the namespace are not assets
+	l.stub.state["config"] = []byte(`{"ID":"config"}`)
+
+	values, err := l.contract.GetAllAssets(l.as("Org1MSP", "alice"))
+	if err != nil {
+		t.Fatalf("GetAllAssets: %v", err)
+	}
+	if len(values) != 1 || values[0].Asset.ID != "a1" {
+		t.Fatalf("unexpected assets: %+v", values)
+	}
+
+	_, err = l.contract.ReadAsset(l.as("Org1MSP", "alice"), "")
+	expectCode(t, err, InvalidArgument)
+}
+
+func TestMalformedAssetValueIsAnError(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	l.stub.state[stateKey("a1")] = []byte(`{"asset":{"ID":42}}`)
+
+	if _, err := l.contract.ReadAsset(l.asAdmin(), "a1"); err == nil {
+		t.Fatalf("ReadAsset decoded a malformed value")
+	}
+	if _, err := l.contract.GetAllAssets(l.asAdmin()); err == nil {
+		t.Fatalf("GetAllAssets decoded a malformed value")
+	}
+}
+
+func TestMigrateAssetKeys(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	// Recreate a1 as a pre-migration asset: bare key, its own policy and history
+	l.stub.startTx("legacy-create")
+	l.stub.PutState("a1", l.stub.state[stateKey("a1")])
+	l.stub.SetStateValidationParameter("a1", l.stub.validation[stateKey("a1")])
+	l.stub.DelState(stateKey("a1"))
+	delete(l.stub.validation, stateKey("a1"))
+	delete(l.stub.history, stateKey("a1"))
+	l.stub.PutState("a2", []byte(`{"ID":"a2","ownerId":"Org1MSP::alice"}`))
+	l.stub.PutState("a3", []byte(`{"ID":"a3","ownerId":"Org1MSP::alice"}`))
+
+	_, err := l.contract.MigrateAssetKeys(l.as("Org1MSP", "alice"), 10)
+	expectCode(t, err, PermissionDenied)
+	_, err = l.contract.MigrateAssetKeys(l.asAdmin(), 0)
+	expectCode(t, err, InvalidArgument)
+
+	result, err := l.contract.MigrateAssetKeys(l.asAdmin(), 2)
+	if err != nil {
+		t.Fatalf("MigrateAssetKeys: %v", err)
+	}
+	if strings.Join(result.Migrated, ",") != "a1,a2" || !result.Remaining {
+		t.Fatalf("first batch = %+v", result)
+	}
+	result, err = l.contract.MigrateAssetKeys(l.asAdmin(), 2)
+	if err != nil {
+		t.Fatalf("MigrateAssetKeys: %v", err)
+	}
+	if strings.Join(result.Migrated, ",") != "a3" || result.Remaining {
+		t.Fatalf("second batch = %+v", result)
+	}
+
+	for _, id := range []string{"a1", "a2", "a3"} {
+		if _, ok := l.stub.state[id]; ok {
+			t.Fatalf("bare key %s not deleted", id)
+		}
+		value, err := l.contract.ReadAsset(l.asAdmin(), id)
+		if err != nil || value.Asset.ID != id {
+			t.Fatalf("ReadAsset(%s) after migration = %+v, %v", id, value, err)
+		}
+	}
+	if got := assetEndorsers(t, l, "a1"); got != "Org1MSP" {
+		t.Fatalf("endorsers after migration = %q, want Org1MSP", got)
+	}
+
+	// History spans the move, without the deletion of the bare key
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	records, err := l.contract.GetAssetHistory(l.asAdmin(), "a1")
+	if err != nil {
+		t.Fatalf("GetAssetHistory: %v", err)
+	}
+	if len(records) != 3 || records[0].TxId != "legacy-create" || records[2].ActionType != TransferProposeActionType {
+		t.Fatalf("unexpected history: %+v", records)
+	}
+}
+
+// moveToLegacyKey turns a created asset into one written before assets were namespaced
+func moveToLegacyKey(l *testLedger, id string) {
+	l.stub.startTx("legacy-" + id)
+	l.stub.PutState(id, l.stub.state[stateKey(id)])
+	l.stub.SetStateValidationParameter(id, l.stub.validation[stateKey(id)])
+	l.stub.DelState(stateKey(id))
+	delete(l.stub.validation, stateKey(id))
+}
+
+func TestLegacyKeyedAssetBeforeMigration(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	moveToLegacyKey(l, "a1")
+
+	value, err := l.contract.ReadAsset(l.as("Org1MSP", "alice"), "a1")
+	if err != nil || value.Asset.ID != "a1" || value.Asset.OwnerID != alice {
+		t.Fatalf("ReadAsset = %+v, %v", value, err)
+	}
+	if exists, err := l.contract.AssetExists(l.as("Org1MSP", "alice"), "a1"); err != nil || !exists {
+		t.Fatalf("AssetExists = %v, %v", exists, err)
+	}
+
+	// The first write moves the asset to its composite key
+	if err := l.contract.UpdateAssetMetadata(l.as("Org1MSP", "alice"), "a1", `{"name":"Renamed"}`); err != nil {
+		t.Fatalf("UpdateAssetMetadata: %v", err)
+	}
+	if _, ok := l.stub.state["a1"]; ok {
+		t.Fatalf("bare key a1 still present after an update")
+	}
+	if value := storedValue(t, l, "a1"); value.Asset.Name != "Renamed" {
+		t.Fatalf("stored name = %q, want Renamed", value.Asset.Name)
+	}
+	if got := assetEndorsers(t, l, "a1"); got != "Org1MSP" {
+		t.Fatalf("endorsers after update = %q, want Org1MSP", got)
+	}
+
+	createPublicAsset(t, l, "a2")
+	moveToLegacyKey(l, "a2")
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a2", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a2"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+	if _, ok := l.stub.state["a2"]; ok {
+		t.Fatalf("bare key a2 still present after a transfer")
+	}
+	if value := storedValue(t, l, "a2"); value.Asset.OwnerID != bob {
+		t.Fatalf("owner after transfer = %q, want %q", value.Asset.OwnerID, bob)
+	}
+	page, err := l.contract.GetAssetsByOwner(l.as("Org2MSP", "bob"), bob, 10, "")
+	if err != nil || len(page.Records) != 1 || page.Records[0].Asset.ID != "a2" {
+		t.Fatalf("GetAssetsByOwner(bob) = %+v, %v", page, err)
+	}
+
+	// Nothing is left for the migration, and nothing is listed twice
+	result, err := l.contract.MigrateAssetKeys(l.asAdmin(), 10)
+	if err != nil || len(result.Migrated) != 0 {
+		t.Fatalf("MigrateAssetKeys = %+v, %v", result, err)
+	}
+	values, err := l.contract.GetAllAssets(l.asAdmin())
+	if err != nil || len(values) != 2 {
+		t.Fatalf("GetAllAssets = %+v, %v", values, err)
+	}
+}
+
+func TestMigrateAssetKeysConflict(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	l.stub.state["a1"] = []byte(`{"ID":"a1","ownerId":"Org1MSP::alice"}`)
+
+	_, err := l.contract.MigrateAssetKeys(l.asAdmin(), 10)
+	expectCode(t, err, Conflict)
+}
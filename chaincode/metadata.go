@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransientMetadataKey is the transient map key carrying a MetadataUpdate, used instead of the
+// argument so that changes to PRIVATE assets stay off the proposal
+const TransientMetadataKey = "asset_metadata"
+
+// Field limits enforced by UpdateAssetMetadata (in characters)
+const (
+	MaxNameLength        = 200
+	MaxDescriptionLength = 4000
+	MaxReferenceLength   = 2048
+)
+
+// MetadataUpdate lists the descriptive fields to change. Omitted fields keep their value;
+// attachments, when present, replace the whole list (an empty list removes them all).
+type MetadataUpdate struct {
+	Name        *string            `json:"name,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	ImageURL    *string            `json:"imageUrl,omitempty"`
+	ImageHash   *string            `json:"imageHash,omitempty"`
+	Attachments *[]AssetAttachment `json:"attachments,omitempty"`
+	// Salt re-seals the details of a PRIVATE asset; the previous salt is kept when it is empty
+	Salt string `json:"salt,omitempty"`
+}
+
+// UpdateAssetMetadata changes the name, description, image reference or attachments of an ACTIVE
+// asset. Only the owner may call it. metadataJSON is a MetadataUpdate; when it is empty the update
+// is read from the transient map under asset_metadata. The details of a PRIVATE asset are
+// rewritten in the owner's collection, so it must be endorsed on the owner's org peer.
+func (s *SmartContract) UpdateAssetMetadata(ctx contractapi.TransactionContextInterface, id string, metadataJSON string) error {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	clientFullID, err := s.getClientFullIdentifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	if value.Asset.OwnerID != clientFullID {
+		return newError(PermissionDenied, "only the owner can update asset metadata")
+	}
+	if err := requireActive(value.Asset); err != nil {
+		return err
+	}
+
+	var update MetadataUpdate
+	if err := readJSONInput(ctx, metadataJSON, TransientMetadataKey, &update); err != nil {
+		return err
+	}
+	if err := update.validate(); err != nil {
+		return err
+	}
+
+	// PRIVATE details are edited in the clear and sealed again
+	private := value.Asset.PrivateDataHash != EmptyTxt
+	salt := update.Salt
+	if private {
+		details, err := restorePrivateDetails(ctx, &value.Asset)
+		if err != nil {
+			return err
+		}
+		if salt == EmptyTxt {
+			salt = details.Salt
+		}
+	}
+	update.apply(&value.Asset)
+	if private {
+		if err := makeAssetPrivate(ctx, &value.Asset, salt); err != nil {
+			return err
+		}
+	}
+
+	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
+	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
+
+	value.Audit = AuditMetadata{
+		Action:    UpdateMetadataActionType,
+		Actor:     clientFullID,
+		Timestamp: now,
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent("UpdateAssetMetadata", valueJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+
+	return putAssetState(ctx, id, valueJSON)
+}
+
+// readJSONInput decodes a JSON argument or, when it is empty, the transient value under
+// transientKey into target. Callers with PRIVATE assets use the transient map so the content
+// stays off the proposal. Unknown fields are rejected so a misspelled field isn't silently ignored.
+func readJSONInput(ctx contractapi.TransactionContextInterface, argument string, transientKey string, target interface{}) error {
+	data := []byte(argument)
+	if argument == EmptyTxt {
+		transientMap, err := ctx.GetStub().GetTransient()
+		if err != nil {
+			return fmt.Errorf("failed to read transient data: %v", err)
+		}
+		data = transientMap[transientKey]
+	}
+	if len(data) == 0 {
+		return newError(InvalidArgument, "no %s supplied", transientKey)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return newError(InvalidArgument, "failed to parse %s: %v", transientKey, err)
+	}
+	return nil
+}
+
+func (u *MetadataUpdate) validate() error {
+	if u.Name == nil && u.Description == nil && u.ImageURL == nil && u.ImageHash == nil && u.Attachments == nil {
+		return newError(InvalidArgument, "metadata update changes no fields")
+	}
+	if u.Name != nil {
+		if strings.TrimSpace(*u.Name) == EmptyTxt {
+			return newError(InvalidArgument, "invalid name: must not be blank")
+		}
+		if err := checkLength("name", *u.Name, MaxNameLength); err != nil {
+			return err
+		}
+	}
+	if u.Description != nil {
+		if err := checkLength("description", *u.Description, MaxDescriptionLength); err != nil {
+			return err
+		}
+	}
+	if u.ImageURL != nil {
+		if err := checkLength("imageUrl", *u.ImageURL, MaxReferenceLength); err != nil {
+			return err
+		}
+	}
+	if u.ImageHash != nil {
+		if err := checkLength("imageHash", *u.ImageHash, MaxReferenceLength); err != nil {
+			return err
+		}
+	}
+	if u.Attachments != nil {
+		if len(*u.Attachments) > MaxAttachments {
+			return newError(InvalidArgument, "invalid attachments: more than %d", MaxAttachments)
+		}
+		for _, attachment := range *u.Attachments {
+			if err := validateAttachment(attachment); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (u *MetadataUpdate) apply(asset *Asset) {
+	if u.Name != nil {
+		asset.Name = strings.TrimSpace(*u.Name)
+	}
+	if u.Description != nil {
+		asset.Description = *u.Description
+	}
+	if u.ImageURL != nil {
+		asset.ImageURL = *u.ImageURL
+	}
+	if u.ImageHash != nil {
+		asset.ImageHash = *u.ImageHash
+	}
+	if u.Attachments != nil {
+		asset.Attachments = *u.Attachments
+		if len(asset.Attachments) == 0 {
+			asset.Attachments = nil
+		}
+	}
+}
+
+func checkLength(field string, value string, max int) error {
+	if !utf8.ValidString(value) {
+		return newError(InvalidArgument, "invalid %s: must be valid UTF-8", field)
+	}
+	if utf8.RuneCountInString(value) > max {
+		return newError(InvalidArgument, "invalid %s: longer than %d characters", field, max)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const validHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestUpdateAssetMetadata(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	err := l.contract.UpdateAssetMetadata(l.as("Org1MSP", "alice"), "a1", `{"name":"  Restored Painting ","attachments":[{"file_name":"appraisal.pdf","file_size":9,"file_hash":"`+validHash+`"}]}`)
+	if err != nil {
+		t.Fatalf("UpdateAssetMetadata: %v", err)
+	}
+
+	value := storedValue(t, l, "a1")
+	if value.Asset.Name != "Restored Painting" || value.Asset.Description != "Oil on canvas" {
+		t.Fatalf("unexpected fields after update: %+v", value.Asset)
+	}
+	if len(value.Asset.Attachments) != 1 || value.Asset.Attachments[0].FileName != "appraisal.pdf" {
+		t.Fatalf("attachments not replaced: %+v", value.Asset.Attachments)
+	}
+	if value.Audit.Action != UpdateMetadataActionType || value.Audit.Actor != alice {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+	if event := l.stub.lastEvent(); event.Name != "UpdateAssetMetadata" {
+		t.Fatalf("event = %q, want UpdateAssetMetadata", event.Name)
+	}
+
+	// An empty list removes them all
+	if err := l.contract.UpdateAssetMetadata(l.as("Org1MSP", "alice"), "a1", `{"attachments":[]}`); err != nil {
+		t.Fatalf("UpdateAssetMetadata: %v", err)
+	}
+	if attachments := storedValue(t, l, "a1").Asset.Attachments; len(attachments) != 0 {
+		t.Fatalf("attachments not removed: %+v", attachments)
+	}
+}
+
+func TestUpdateAssetMetadataValidation(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	cases := map[string]string{
+		`{}`:              "changes no fields",
+		`{"desc":"typo"}`: "unknown field",
+		`{"name":"   "}`:  "invalid name",
+		`{"name":"` + strings.Repeat("x", MaxNameLength+1) + `"}`:   "invalid name",
+		`{"attachments":[{"file_name":"a.pdf","file_hash":"abc"}]}`: "invalid attachment.file_hash",
+		`{"attachments":[{"file_hash":"` + validHash + `"}]}`:       "invalid attachment.file_name",
+		`{"attachment":{}}`: "unknown field",
+		`not json`:          "failed to parse",
+	}
+	for update, want := range cases {
+		err := l.contract.UpdateAssetMetadata(l.as("Org1MSP", "alice"), "a1", update)
+		expectCode(t, err, InvalidArgument)
+		expectError(t, err, want)
+	}
+	expectCode(t, l.contract.UpdateAssetMetadata(l.as("Org1MSP", "alice"), "a1", ""), InvalidArgument)
+
+	err := l.contract.UpdateAssetMetadata(l.as("Org2MSP", "bob"), "a1", `{"name":"Mine"}`)
+	expectCode(t, err, PermissionDenied)
+
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a1", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	err = l.contract.UpdateAssetMetadata(l.as("Org1MSP", "alice"), "a1", `{"name":"Thawed"}`)
+	expectError(t, err, FrozenStatus)
+}
+
+func TestUpdatePrivateAssetMetadata(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+
+	ctx := l.as("Org1MSP", "alice")
+	l.stub.transient = map[string][]byte{TransientMetadataKey: []byte(`{"description":"Provenance confirmed"}`)}
+	if err := l.contract.UpdateAssetMetadata(ctx, "p1", ""); err != nil {
+		t.Fatalf("UpdateAssetMetadata: %v", err)
+	}
+
+	asset := storedValue(t, l, "p1").Asset
+	if asset.Name != "" || asset.Description != "" {
+		t.Fatalf("private fields leaked into world state: %+v", asset)
+	}
+	stored := l.stub.private[implicitCollection("Org1MSP")]["p1"]
+	if asset.PrivateDataHash != hashBytes(stored) {
+		t.Fatalf("public hash does not match the rewritten details")
+	}
+	var details AssetPrivateDetails
+	json.Unmarshal(stored, &details)
+	if details.Name != "Secret Painting" || details.Description != "Provenance confirmed" || details.Salt != testSalt {
+		t.Fatalf("unexpected private details: %+v", details)
+	}
+
+	ctx = l.as("Org1MSP", "alice")
+	l.stub.transient = map[string][]byte{TransientMetadataKey: []byte(`{"name":"Renamed","salt":"fresh-salt-0123456789"}`)}
+	if err := l.contract.UpdateAssetMetadata(ctx, "p1", ""); err != nil {
+		t.Fatalf("UpdateAssetMetadata: %v", err)
+	}
+	json.Unmarshal(l.stub.private[implicitCollection("Org1MSP")]["p1"], &details)
+	if details.Name != "Renamed" || details.Salt != "fresh-salt-0123456789" {
+		t.Fatalf("unexpected resealed details: %+v", details)
+	}
+}
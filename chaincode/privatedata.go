@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransientAssetKey is the transient map key carrying AssetPrivateDetails from the backend
+const TransientAssetKey = "asset_properties"
+
+// AssetPrivateDetails holds the fields of a PRIVATE asset that are kept in the owner's implicit
+// org collection. Only their SHA-256 hash (Asset.PrivateDataHash) is stored in the world state.
+type AssetPrivateDetails struct {
+	ID          string            `json:"ID"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	ImageURL    string            `json:"imageUrl"`
+	ImageHash   string            `json:"imageHash"`
+	Attachments []AssetAttachment `json:"attachments,omitempty" metadata:",optional"`
+	Salt        string            `json:"salt"` // Random value from the client so the public hash can't be brute-forced
+}
+
+// implicitCollection returns the name of an organization's implicit private data collection
+func implicitCollection(mspid string) string {
+	return "_implicit_org_" + mspid
+}
+
+// ReadAssetPrivateDetails returns the private fields of a PRIVATE asset to its owner or proposed owner.
+// It must be evaluated on a peer of the caller's organization.
+func (s *SmartContract) ReadAssetPrivateDetails(ctx contractapi.TransactionContextInterface, id string) (*AssetPrivateDetails, error) {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	clientFullID, err := s.getClientFullIdentifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if value.Asset.OwnerID != clientFullID && value.Asset.ProposedOwnerID != clientFullID {
+		return nil, newError(PermissionDenied, "only the owner or proposed owner can read private details")
+	}
+	if value.Asset.PrivateDataHash == EmptyTxt {
+		return nil, newError(NotFound, "asset %s has no private details", id)
+	}
+
+	detailsJSON, err := ctx.GetStub().GetPrivateData(implicitCollection(mspFromFullID(clientFullID)), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private details: %v", err)
+	}
+	if detailsJSON == nil {
+		return nil, newError(NotFound, "private details for asset %s are not available to your organization", id)
+	}
+	if hashBytes(detailsJSON) != value.Asset.PrivateDataHash {
+		return nil, fmt.Errorf("private details for asset %s do not match the public hash", id)
+	}
+
+	return storedPrivateDetails(detailsJSON)
+}
+
+// readTransientDetails returns the private details passed in the transient map, if any
+func readTransientDetails(ctx contractapi.TransactionContextInterface) (*AssetPrivateDetails, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient data: %v", err)
+	}
+
+	detailsJSON, ok := transientMap[TransientAssetKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var details AssetPrivateDetails
+	if err := json.Unmarshal(detailsJSON, &details); err != nil {
+		return nil, newError(InvalidArgument, "failed to parse transient %s: %v", TransientAssetKey, err)
+	}
+	return &details, nil
+}
+
+// MinSaltLength is the shortest salt accepted for private details. The salt must come from the
+// client: anything derived from the transaction (such as its ID) is public in the block.
+const MinSaltLength = 16
+
+// makeAssetPrivate moves the asset's private fields into the owner's implicit collection and
+// replaces them on the public asset with their salted hash
+func makeAssetPrivate(ctx contractapi.TransactionContextInterface, asset *Asset, salt string) error {
+	if len(salt) < MinSaltLength {
+		return newError(InvalidArgument, "private details need a random salt of at least %d characters", MinSaltLength)
+	}
+	details := privateDetailsOf(*asset)
+	details.Salt = salt
+
+	hash, err := putPrivateDetails(ctx, mspFromFullID(asset.OwnerID), &details)
+	if err != nil {
+		return err
+	}
+
+	asset.Name = EmptyTxt
+	asset.Description = EmptyTxt
+	asset.ImageURL = EmptyTxt
+	asset.ImageHash = EmptyTxt
+	asset.Attachments = nil
+	asset.PrivateDataHash = hash
+	return nil
+}
+
+// makeAssetPublic restores the private fields from the owner's collection onto the public asset
+func makeAssetPublic(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if asset.PrivateDataHash == EmptyTxt {
+		return nil
+	}
+
+	if _, err := restorePrivateDetails(ctx, asset); err != nil {
+		return err
+	}
+	asset.PrivateDataHash = EmptyTxt
+
+	return ctx.GetStub().DelPrivateData(implicitCollection(mspFromFullID(asset.OwnerID)), asset.ID)
+}
+
+// restorePrivateDetails copies the details from the owner's collection onto asset after
+// checking them against the public hash, and returns them. The collection and the hash are left
+// as they are.
+func restorePrivateDetails(ctx contractapi.TransactionContextInterface, asset *Asset) (*AssetPrivateDetails, error) {
+	detailsJSON, err := ctx.GetStub().GetPrivateData(implicitCollection(mspFromFullID(asset.OwnerID)), asset.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private details: %v", err)
+	}
+	if detailsJSON == nil || hashBytes(detailsJSON) != asset.PrivateDataHash {
+		return nil, fmt.Errorf("private details for asset %s are unavailable or do not match the public hash", asset.ID)
+	}
+
+	details, err := storedPrivateDetails(detailsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	asset.Name = details.Name
+	asset.Description = details.Description
+	asset.ImageURL = details.ImageURL
+	asset.ImageHash = details.ImageHash
+	asset.Attachments = details.Attachments
+	return details, nil
+}
+
+// sharePrivateDetails copies a PRIVATE asset's details into the recipient org's collection
+// when a transfer is proposed across organizations
+func sharePrivateDetails(ctx contractapi.TransactionContextInterface, asset Asset, recipientID string) error {
+	ownerMSP := mspFromFullID(asset.OwnerID)
+	recipientMSP := mspFromFullID(recipientID)
+	if asset.PrivateDataHash == EmptyTxt || recipientMSP == EmptyTxt || recipientMSP == ownerMSP {
+		return nil
+	}
+
+	detailsJSON, err := ctx.GetStub().GetPrivateData(implicitCollection(ownerMSP), asset.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read private details: %v", err)
+	}
+	if detailsJSON == nil || hashBytes(detailsJSON) != asset.PrivateDataHash {
+		return fmt.Errorf("private details for asset %s are unavailable or do not match the public hash", asset.ID)
+	}
+
+	return ctx.GetStub().PutPrivateData(implicitCollection(recipientMSP), asset.ID, detailsJSON)
+}
+
+// settlePrivateDetails finishes a cross-org transfer of a PRIVATE asset: the copy shared at proposal
+// time must match the public hash (checked via GetPrivateDataHash, which works on every peer), and
+// the collection of the org that no longer holds the asset is cleared.
+func settlePrivateDetails(ctx contractapi.TransactionContextInterface, asset Asset, keepOwnerID string, dropOwnerID string) error {
+	keepMSP := mspFromFullID(keepOwnerID)
+	dropMSP := mspFromFullID(dropOwnerID)
+	if asset.PrivateDataHash == EmptyTxt || dropMSP == EmptyTxt || dropMSP == keepMSP {
+		return nil
+	}
+
+	hash, err := ctx.GetStub().GetPrivateDataHash(implicitCollection(keepMSP), asset.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read private details hash: %v", err)
+	}
+	if hex.EncodeToString(hash) != asset.PrivateDataHash {
+		return fmt.Errorf("private details for asset %s are missing from %s", asset.ID, keepMSP)
+	}
+
+	return ctx.GetStub().DelPrivateData(implicitCollection(dropMSP), asset.ID)
+}
+
+func putPrivateDetails(ctx contractapi.TransactionContextInterface, mspid string, details *AssetPrivateDetails) (string, error) {
+	if mspid == EmptyTxt {
+		return EmptyTxt, newError(InvalidArgument, "cannot store private details for an owner without an MSP")
+	}
+
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return EmptyTxt, err
+	}
+	if err := ctx.GetStub().PutPrivateData(implicitCollection(mspid), details.ID, detailsJSON); err != nil {
+		return EmptyTxt, fmt.Errorf("failed to put private details: %v", err)
+	}
+	return hashBytes(detailsJSON), nil
+}
+
+func privateDetailsOf(asset Asset) AssetPrivateDetails {
+	return AssetPrivateDetails{
+		ID:          asset.ID,
+		Name:        asset.Name,
+		Description: asset.Description,
+		ImageURL:    asset.ImageURL,
+		ImageHash:   asset.ImageHash,
+		Attachments: asset.Attachments,
+	}
+}
+
+func hashBytes(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const testSalt = "5a1f0c9e7b2d4e68"
+
+// salted adds the transient salt the backend sends with every call that seals private details
+func salted(l *testLedger, ctx contractapi.TransactionContextInterface) contractapi.TransactionContextInterface {
+	l.stub.transient = map[string][]byte{TransientAssetKey: []byte(`{"salt":"` + testSalt + `"}`)}
+	return ctx
+}
+
+func createPrivateAsset(t *testing.T, l *testLedger, id string) {
+	t.Helper()
+	details, _ := json.Marshal(AssetPrivateDetails{
+		Name:        "Secret Painting",
+		Description: "Provenance withheld",
+		ImageURL:    "https://img/secret",
+		Attachments: []AssetAttachment{{FileName: "deed.pdf", FileSize: 7}},
+		Salt:        testSalt,
+	})
+	ctx := l.as("Org1MSP", "alice")
+	l.stub.transient = map[string][]byte{TransientAssetKey: details}
+	if _, err := l.contract.CreateAsset(ctx, id, "", "", "", "", PrivateView, "", 0, "", "", "", ""); err != nil {
+		t.Fatalf("CreateAsset(%s): %v", id, err)
+	}
+}
+
+func TestCreatePrivateAsset(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+
+	asset := storedValue(t, l, "p1").Asset
+	if asset.Name != "" || asset.Description != "" || len(asset.Attachments) != 0 {
+		t.Fatalf("private fields leaked into world state: %+v", asset)
+	}
+	stored := l.stub.private[implicitCollection("Org1MSP")]["p1"]
+	if stored == nil {
+		t.Fatalf("private details not written to the owner's collection")
+	}
+	if asset.PrivateDataHash != hashBytes(stored) {
+		t.Fatalf("public hash does not match the stored details")
+	}
+}
+
+func TestCreatePrivateAssetRequiresSalt(t *testing.T) {
+	l := newTestLedger()
+	ctx := l.as("Org1MSP", "alice")
+	_, err := l.contract.CreateAsset(ctx, "p1", "Named", "desc", "", "", PrivateView, "", 0, "", "", "", "")
+	expectCode(t, err, InvalidArgument)
+
+	l.stub.transient = map[string][]byte{TransientAssetKey: []byte(`{"name":"Named","salt":"short"}`)}
+	_, err = l.contract.CreateAsset(ctx, "p1", "", "", "", "", PrivateView, "", 0, "", "", "", "")
+	expectCode(t, err, InvalidArgument)
+	if l.stub.private[implicitCollection("Org1MSP")]["p1"] != nil {
+		t.Fatalf("details stored without a usable salt")
+	}
+}
+
+func TestReadAssetPrivateDetails(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+	createPublicAsset(t, l, "a1")
+
+	details, err := l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "alice"), "p1")
+	if err != nil {
+		t.Fatalf("ReadAssetPrivateDetails: %v", err)
+	}
+	if details.Name != "Secret Painting" || details.Salt != testSalt {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+
+	_, err = l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "carol"), "p1")
+	expectError(t, err, "only the owner or proposed owner")
+	_, err = l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "alice"), "a1")
+	expectError(t, err, "no private details")
+
+	l.stub.private[implicitCollection("Org1MSP")]["p1"] = []byte(`{"name":"tampered"}`)
+	_, err = l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "alice"), "p1")
+	expectError(t, err, "do not match")
+}
+
+func TestPrivateAssetCrossOrgTransfer(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "p1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if l.stub.private[implicitCollection("Org2MSP")]["p1"] == nil {
+		t.Fatalf("details not shared with the recipient org")
+	}
+	if _, err := l.contract.ReadAssetPrivateDetails(l.as("Org2MSP", "bob"), "p1"); err != nil {
+		t.Fatalf("proposed owner cannot read details: %v", err)
+	}
+
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "p1"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+	if l.stub.private[implicitCollection("Org1MSP")]["p1"] != nil {
+		t.Fatalf("sender org still holds the details after accept")
+	}
+	if _, err := l.contract.ReadAssetPrivateDetails(l.as("Org2MSP", "bob"), "p1"); err != nil {
+		t.Fatalf("new owner cannot read details: %v", err)
+	}
+}
+
+func TestPrivateAssetRejectClearsRecipientCopy(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "p1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if err := l.contract.RejectTransfer(l.as("Org2MSP", "bob"), "p1"); err != nil {
+		t.Fatalf("RejectTransfer: %v", err)
+	}
+	if l.stub.private[implicitCollection("Org2MSP")]["p1"] != nil {
+		t.Fatalf("recipient org still holds the details after reject")
+	}
+	if l.stub.private[implicitCollection("Org1MSP")]["p1"] == nil {
+		t.Fatalf("owner org lost the details after reject")
+	}
+}
+
+func TestAcceptFailsWhenSharedCopyIsMissing(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "p1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	delete(l.stub.private[implicitCollection("Org2MSP")], "p1")
+
+	expectError(t, l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "p1"), "missing from Org2MSP")
+}
+
+func TestUpdateAssetViewMovesDetails(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+
+	if err := l.contract.UpdateAssetView(l.as("Org1MSP", "alice"), "p1", PublicView); err != nil {
+		t.Fatalf("UpdateAssetView to PUBLIC: %v", err)
+	}
+	asset := storedValue(t, l, "p1").Asset
+	if asset.Name != "Secret Painting" || asset.PrivateDataHash != "" {
+		t.Fatalf("details not restored on publish: %+v", asset)
+	}
+	if l.stub.private[implicitCollection("Org1MSP")]["p1"] != nil {
+		t.Fatalf("private copy not removed on publish")
+	}
+
+	ctx := l.as("Org1MSP", "alice")
+	l.stub.transient = map[string][]byte{TransientAssetKey: []byte(`{"salt":"again-salt-0123456789"}`)}
+	if err := l.contract.UpdateAssetView(ctx, "p1", PrivateView); err != nil {
+		t.Fatalf("UpdateAssetView to PRIVATE: %v", err)
+	}
+	asset = storedValue(t, l, "p1").Asset
+	if asset.Name != "" || asset.PrivateDataHash == "" {
+		t.Fatalf("details not hidden again: %+v", asset)
+	}
+
+	details, err := l.contract.ReadAssetPrivateDetails(l.as("Org1MSP", "alice"), "p1")
+	if err != nil {
+		t.Fatalf("ReadAssetPrivateDetails: %v", err)
+	}
+	if details.Name != "Secret Painting" || details.Salt != "again-salt-0123456789" {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+}
+
+func TestDeleteAssetHidesPublicDetails(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	if err := l.contract.DeleteAsset(salted(l, l.as("Org1MSP", "alice")), "a1"); err != nil {
+		t.Fatalf("DeleteAsset: %v", err)
+	}
+	asset := storedValue(t, l, "a1").Asset
+	if asset.Name != "" || asset.PrivateDataHash == "" {
+		t.Fatalf("deleted asset still exposes details: %+v", asset)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -34,9 +35,16 @@ const (
 	CreateActionType      = "CREATE"
 	UpdateStatusActionType = "UPDATE_STATUS"
 	UpdateViewActionType  = "UPDATE_VIEW"
+	UpdateMetadataActionType = "UPDATE_METADATA"
+	AttachmentAddActionType     = "ATTACHMENT_ADD"
+	AttachmentReplaceActionType = "ATTACHMENT_REPLACE"
+	AttachmentRemoveActionType  = "ATTACHMENT_REMOVE"
 	DeleteActionType      = "DELETE"
 	TransferProposeActionType = "TRANSFER_PROPOSE"
 	TransferAcceptActionType  = "TRANSFER_ACCEPT"
+	TransferRejectActionType  = "TRANSFER_REJECT"
+	TransferCancelActionType  = "TRANSFER_CANCEL"
+	TransferExpireActionType  = "TRANSFER_EXPIRE"
 )
 
 // SmartContract provides functions for managing an Asset
@@ -60,11 +68,13 @@ type Asset struct {
 	Description     string `json:"description"`
 	OwnerID         string `json:"ownerId"` 
 	ProposedOwnerID string `json:"proposedOwnerId"`
+	TransferExpiresAt string `json:"transferExpiresAt"` // RFC3339, empty when the proposal never expires
 	ImageURL        string `json:"imageUrl"`
 	ImageHash       string `json:"imageHash"`
 	Status          string `json:"status"` 
 	View            string `json:"view"` 
-	Attachment      AssetAttachment `json:"attachment"`
+	Attachments     []AssetAttachment `json:"attachments,omitempty" metadata:",optional"` // Ordered; see attachments.go
+	PrivateDataHash string `json:"privateDataHash"` // Set when the fields above live in the owner's private collection
 }
 
 // AuditMetadata contains the metadata for a state change
@@ -90,6 +100,13 @@ type HistoryRecord struct {
 	IsDelete   bool      `json:"isDelete"`
 }
 
+// PaginatedQueryResult structure for returning one page of assets and the bookmark for the next page
+type PaginatedQueryResult struct {
+	Records             []*LedgerValue `json:"records"`
+	FetchedRecordsCount int32          `json:"fetchedRecordsCount"`
+	Bookmark            string         `json:"bookmark"`
+}
+
 // InitLedger adds a base set of assets to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
@@ -111,7 +128,11 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		return err
 	}
 
-	return ctx.GetStub().PutState(asset.ID, valueJSON)
+	if err := putAssetState(ctx, asset.ID, valueJSON); err != nil {
+		return err
+	}
+
+	return setAssetEndorsers(ctx, asset.ID, asset.OwnerID)
 }
 
 func (s *SmartContract) getClientFullIdentifier(ctx contractapi.TransactionContextInterface) (string, error) {
@@ -136,20 +157,27 @@ func (s *SmartContract) getClientFullIdentifier(ctx contractapi.TransactionConte
 	return fmt.Sprintf("%s::%s", mspid, username), nil
 }
 
-// CreateAsset issues a new asset to the world state
+// CreateAsset issues a new asset to the world state and returns its ID. When id is empty, the
+// ID is derived from the transaction ID.
 func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, name string, description string, imageURL string, imageHash string, view string, 
-	fileName string, fileSize int64, fileHash string, ipfsCID string, storagePath string, storageType string) error {
+	fileName string, fileSize int64, fileHash string, ipfsCID string, storagePath string, storageType string) (string, error) {
+	if id == EmptyTxt {
+		id = newAssetID(ctx)
+	}
 	exists, err := s.AssetExists(ctx, id)
 	if err != nil {
-		return err
+		return EmptyTxt, err
 	}
 	if exists {
-		return fmt.Errorf("the asset %s already exists", id)
+		return EmptyTxt, newError(AlreadyExists, "the asset %s already exists", id)
+	}
+	if hasRole(ctx, AuditorRole) {
+		return EmptyTxt, newError(PermissionDenied, "auditors have read-only access")
 	}
 
 	clientFullID, err := s.getClientFullIdentifier(ctx)
 	if err != nil {
-		return err
+		return EmptyTxt, err
 	}
 
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
@@ -165,14 +193,37 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		ImageHash:       imageHash,
 		Status:          ActiveStatus,
 		View:            view,
-		Attachment: AssetAttachment{
+	}
+	// The arguments describe the first attachment; more are added with AddAttachment
+	if fileName != EmptyTxt || fileHash != EmptyTxt {
+		asset.Attachments = []AssetAttachment{{
 			FileName:    fileName,
 			FileSize:    fileSize,
 			FileHash:    fileHash,
 			IpfsCID:     ipfsCID,
 			StoragePath: storagePath,
 			StorageType: storageType,
-		},
+		}}
+	}
+
+	// PRIVATE assets keep their details in the owner's org collection; the backend sends them as transient data
+	if view == PrivateView {
+		details, err := readTransientDetails(ctx)
+		if err != nil {
+			return EmptyTxt, err
+		}
+		salt := EmptyTxt
+		if details != nil {
+			asset.Name = details.Name
+			asset.Description = details.Description
+			asset.ImageURL = details.ImageURL
+			asset.ImageHash = details.ImageHash
+			asset.Attachments = details.Attachments
+			salt = details.Salt
+		}
+		if err := makeAssetPrivate(ctx, &asset, salt); err != nil {
+			return EmptyTxt, err
+		}
 	}
 
 	ledgerValue := LedgerValue{
@@ -186,46 +237,43 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 
 	valueJSON, err := json.Marshal(ledgerValue)
 	if err != nil {
-		return err
+		return EmptyTxt, err
 	}
 
 	err = ctx.GetStub().SetEvent("CreateAsset", valueJSON)
 	if err != nil {
-		return fmt.Errorf("failed to set event: %v", err)
+		return EmptyTxt, fmt.Errorf("failed to set event: %v", err)
 	}
 
-	return ctx.GetStub().PutState(id, valueJSON)
+	if err := putAssetState(ctx, id, valueJSON); err != nil {
+		return EmptyTxt, err
+	}
+
+	// Later writes to this asset must be endorsed by the owner's organization
+	if err := setAssetEndorsers(ctx, id, clientFullID); err != nil {
+		return EmptyTxt, err
+	}
+	return id, nil
 }
 
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*LedgerValue, error) {
-	valueJSON, err := ctx.GetStub().GetState(id)
+	valueJSON, err := getAssetState(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get asset: %v", err)
+		return nil, prefixError(err, "failed to get asset")
 	}
 	if valueJSON == nil {
-		return nil, fmt.Errorf("asset %s does not exist", id)
+		return nil, newError(NotFound, "asset %s does not exist", id)
 	}
-	var value LedgerValue
-	
-	var temp map[string]interface{}
-	json.Unmarshal(valueJSON, &temp)
-	
-	// Flexible check for "asset" or "Asset"
-	if _, ok := temp["asset"]; ok || temp["Asset"] != nil {
-		err = json.Unmarshal(valueJSON, &value)
-	} else {
-		// Legacy fallback
-		var asset Asset
-		json.Unmarshal(valueJSON, &asset)
-		value.Asset = asset
-		value.Audit = AuditMetadata{Action: "LEGACY", Actor: "Unknown", Timestamp: ""}
+	value, err := storedLedgerValue(valueJSON)
+	if err != nil {
+		return nil, prefixError(err, "failed to read asset %s", id)
 	}
-	
 	return &value, nil
 }
 
 // ProposeTransfer initiates the Two-Factor Transfer workflow.
-func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwnerID string) error {
+// expiresAt is an optional RFC3339 deadline after which anyone may revert the proposal via ExpireTransfer.
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwnerID string, expiresAt string) error {
 	value, err := s.ReadAsset(ctx, id)
 	if err != nil {
 		return err
@@ -237,17 +285,36 @@ func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterf
 	}
 
 	if value.Asset.OwnerID != clientFullID {
-		return fmt.Errorf("only the owner can propose a transfer")
+		return newError(PermissionDenied, "only the owner can propose a transfer")
 	}
-	if value.Asset.Status != ActiveStatus {
-		return fmt.Errorf("asset is not ACTIVE")
+	if err := transitionStatus(&value.Asset, PendingTransferStatus); err != nil {
+		return err
+	}
+	if newOwnerID == EmptyTxt || newOwnerID == clientFullID {
+		return newError(InvalidArgument, "invalid transfer recipient")
 	}
 
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
-	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
+	txTime := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))
+	now := txTime.Format(time.RFC3339)
+
+	if expiresAt != EmptyTxt {
+		expiry, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return newError(InvalidArgument, "invalid expiry timestamp %s: %v", expiresAt, err)
+		}
+		if !expiry.After(txTime) {
+			return newError(InvalidArgument, "transfer expiry must be in the future")
+		}
+		expiresAt = expiry.UTC().Format(time.RFC3339)
+	}
+
+	if err := sharePrivateDetails(ctx, value.Asset, newOwnerID); err != nil {
+		return err
+	}
 
-	value.Asset.Status = PendingTransferStatus
 	value.Asset.ProposedOwnerID = newOwnerID
+	value.Asset.TransferExpiresAt = expiresAt
 	
 	value.Audit = AuditMetadata{
 		Action:    TransferProposeActionType,
@@ -265,7 +332,12 @@ func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterf
 		return fmt.Errorf("failed to set event: %v", err)
 	}
 
-	return ctx.GetStub().PutState(id, valueJSON)
+	if err := putAssetState(ctx, id, valueJSON); err != nil {
+		return err
+	}
+
+	// Escrow: accepting a cross-org transfer needs endorsement from both sending and receiving orgs
+	return setAssetEndorsers(ctx, id, value.Asset.OwnerID, newOwnerID)
 }
 
 // AcceptTransfer finalizes the Two-Factor Transfer workflow.
@@ -281,18 +353,30 @@ func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterfa
 	}
 
 	if value.Asset.Status != PendingTransferStatus {
-		return fmt.Errorf("asset is not in PENDING_TRANSFER state")
+		return newError(Conflict, "asset is not in PENDING_TRANSFER state")
 	}
 	if value.Asset.ProposedOwnerID != clientFullID {
-		return fmt.Errorf("you are not the proposed owner")
+		return newError(PermissionDenied, "you are not the proposed owner")
 	}
 
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
-	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
+	txTime := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))
+	now := txTime.Format(time.RFC3339)
+
+	if transferExpired(value.Asset, txTime) {
+		return newError(Conflict, "the transfer proposal expired at %s", value.Asset.TransferExpiresAt)
+	}
+
+	if err := settlePrivateDetails(ctx, value.Asset, value.Asset.ProposedOwnerID, value.Asset.OwnerID); err != nil {
+		return err
+	}
 
+	if err := transitionStatus(&value.Asset, ActiveStatus); err != nil {
+		return err
+	}
 	value.Asset.OwnerID = value.Asset.ProposedOwnerID
 	value.Asset.ProposedOwnerID = ""
-	value.Asset.Status = ActiveStatus
+	value.Asset.TransferExpiresAt = EmptyTxt
 	
 	value.Audit = AuditMetadata{
 		Action:    TransferAcceptActionType,
@@ -310,19 +394,146 @@ func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("failed to set event: %v", err)
 	}
 
-	return ctx.GetStub().PutState(id, valueJSON)
+	if err := putAssetState(ctx, id, valueJSON); err != nil {
+		return err
+	}
+
+	return setAssetEndorsers(ctx, id, value.Asset.OwnerID)
 }
 
-// UpdateAssetStatus allows an authority to change the status of an asset
-func (s *SmartContract) UpdateAssetStatus(ctx contractapi.TransactionContextInterface, id string, newStatus string) error {
-	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+// RejectTransfer lets the proposed owner decline a pending transfer, returning the asset to ACTIVE.
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	clientFullID, err := s.getClientFullIdentifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	if value.Asset.Status != PendingTransferStatus {
+		return newError(Conflict, "asset is not in PENDING_TRANSFER state")
+	}
+	if value.Asset.ProposedOwnerID != clientFullID {
+		return newError(PermissionDenied, "only the proposed owner can reject a transfer")
+	}
+
+	return s.revertTransfer(ctx, value, TransferRejectActionType, clientFullID, "RejectTransfer")
+}
+
+// CancelTransfer lets the current owner withdraw a pending transfer, returning the asset to ACTIVE.
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	clientFullID, err := s.getClientFullIdentifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	if value.Asset.Status != PendingTransferStatus {
+		return newError(Conflict, "asset is not in PENDING_TRANSFER state")
+	}
+	if value.Asset.OwnerID != clientFullID {
+		return newError(PermissionDenied, "only the owner can cancel a transfer")
+	}
+
+	return s.revertTransfer(ctx, value, TransferCancelActionType, clientFullID, "CancelTransfer")
+}
+
+// ExpireTransfer reverts a pending transfer whose expiry has passed. Any client may call it.
+func (s *SmartContract) ExpireTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	clientFullID, err := s.getClientFullIdentifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	if value.Asset.Status != PendingTransferStatus {
+		return newError(Conflict, "asset is not in PENDING_TRANSFER state")
+	}
+
+	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
+	txTime := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))
+	if !transferExpired(value.Asset, txTime) {
+		return newError(Conflict, "the transfer proposal has not expired")
+	}
+
+	return s.revertTransfer(ctx, value, TransferExpireActionType, clientFullID, "ExpireTransfer")
+}
+
+// revertTransfer clears a pending proposal and returns the asset to ACTIVE with the original owner
+func (s *SmartContract) revertTransfer(ctx contractapi.TransactionContextInterface, value *LedgerValue, action string, actor string, eventName string) error {
+	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
+	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
+
+	if err := transitionStatus(&value.Asset, ActiveStatus); err != nil {
+		return err
+	}
+	if err := clearProposal(ctx, &value.Asset); err != nil {
+		return err
+	}
+
+	value.Audit = AuditMetadata{
+		Action:    action,
+		Actor:     actor,
+		Timestamp: now,
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, valueJSON)
 	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+
+	if err := putAssetState(ctx, value.Asset.ID, valueJSON); err != nil {
 		return err
 	}
 
-	isAdmin, found, _ := ctx.GetClientIdentity().GetAttributeValue("admin")
-	if (!found || isAdmin != "true") && clientMSPID != "Org1MSP" && clientMSPID != "Org2MSP" {
-		return fmt.Errorf("administrative access required")
+	return setAssetEndorsers(ctx, value.Asset.ID, value.Asset.OwnerID)
+}
+
+// clearProposal drops a pending proposal, leaving the original owner with the only copy of any private details
+func clearProposal(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if asset.ProposedOwnerID == EmptyTxt {
+		return nil
+	}
+	if err := settlePrivateDetails(ctx, *asset, asset.OwnerID, asset.ProposedOwnerID); err != nil {
+		return err
+	}
+	asset.ProposedOwnerID = EmptyTxt
+	asset.TransferExpiresAt = EmptyTxt
+	return nil
+}
+
+// transferExpired reports whether a pending proposal's deadline has passed at txTime
+func transferExpired(asset Asset, txTime time.Time) bool {
+	if asset.TransferExpiresAt == EmptyTxt {
+		return false
+	}
+	expiry, err := time.Parse(time.RFC3339, asset.TransferExpiresAt)
+	if err != nil {
+		return false
+	}
+	return !txTime.Before(expiry)
+}
+
+// UpdateAssetStatus allows an authority to change the status of an asset
+func (s *SmartContract) UpdateAssetStatus(ctx contractapi.TransactionContextInterface, id string, newStatus string) error {
+	if err := requireRole(ctx, AdminRole); err != nil {
+		return prefixError(err, "administrative access required")
 	}
 
 	value, err := s.ReadAsset(ctx, id)
@@ -338,7 +549,19 @@ func (s *SmartContract) UpdateAssetStatus(ctx contractapi.TransactionContextInte
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
 	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
 
-	value.Asset.Status = newStatus
+	// PENDING_TRANSFER needs a recipient, so it can only be entered through ProposeTransfer
+	if newStatus == PendingTransferStatus {
+		return newError(InvalidArgument, "use ProposeTransfer to move asset %s to %s", id, PendingTransferStatus)
+	}
+	// Leaving PENDING_TRANSFER (admin release or revocation) withdraws the open proposal
+	hadProposal := value.Asset.ProposedOwnerID != EmptyTxt
+	if err := transitionStatus(&value.Asset, newStatus); err != nil {
+		return err
+	}
+	if err := clearProposal(ctx, &value.Asset); err != nil {
+		return err
+	}
+
 	value.Audit = AuditMetadata{
 		Action:    UpdateStatusActionType,
 		Actor:     clientFullID + "_ADMIN",
@@ -355,7 +578,14 @@ func (s *SmartContract) UpdateAssetStatus(ctx contractapi.TransactionContextInte
 		return fmt.Errorf("failed to set event: %v", err)
 	}
 
-	return ctx.GetStub().PutState(id, valueJSON)
+	if err := putAssetState(ctx, id, valueJSON); err != nil {
+		return err
+	}
+
+	if hadProposal {
+		return setAssetEndorsers(ctx, id, value.Asset.OwnerID)
+	}
+	return nil
 }
 
 // UpdateAssetView allows the owner to change the visibility of an asset
@@ -371,16 +601,23 @@ func (s *SmartContract) UpdateAssetView(ctx contractapi.TransactionContextInterf
 	}
 
 	if value.Asset.OwnerID != clientFullID {
-		return fmt.Errorf("only the owner can change asset visibility")
+		return newError(PermissionDenied, "only the owner can change asset visibility")
 	}
 
 	if newView != PublicView && newView != PrivateView {
-		return fmt.Errorf("invalid view status")
+		return newError(InvalidArgument, "invalid view status")
+	}
+	if err := requireActive(value.Asset); err != nil {
+		return err
 	}
 
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
 	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
 
+	if err := s.moveAssetDetails(ctx, &value.Asset, newView); err != nil {
+		return err
+	}
+
 	value.Asset.View = newView
 	value.Audit = AuditMetadata{
 		Action:    UpdateViewActionType,
@@ -398,7 +635,7 @@ func (s *SmartContract) UpdateAssetView(ctx contractapi.TransactionContextInterf
 		return fmt.Errorf("failed to set event: %v", err)
 	}
 
-	return ctx.GetStub().PutState(id, valueJSON)
+	return putAssetState(ctx, id, valueJSON)
 }
 
 // DeleteAsset marks an asset as DELETED and restricts visibility (Soft Delete)
@@ -414,14 +651,23 @@ func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface,
 	}
 
 	if value.Asset.OwnerID != clientFullID {
-		return fmt.Errorf("only the owner can delete this asset")
+		return newError(PermissionDenied, "only the owner can delete this asset")
 	}
 
 	txTimestamp, _ := ctx.GetStub().GetTxTimestamp()
 	now := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
 
-	// Soft Delete: Mark as DELETED and hide from public discovery
-	value.Asset.Status = DeletedStatus
+	// Soft Delete: Mark as DELETED, withdraw any open proposal and hide from public discovery
+	hadProposal := value.Asset.ProposedOwnerID != EmptyTxt
+	if err := transitionStatus(&value.Asset, DeletedStatus); err != nil {
+		return err
+	}
+	if err := clearProposal(ctx, &value.Asset); err != nil {
+		return err
+	}
+	if err := s.moveAssetDetails(ctx, &value.Asset, PrivateView); err != nil {
+		return err
+	}
 	value.Asset.View = PrivateView
 	
 	value.Audit = AuditMetadata{
@@ -440,47 +686,103 @@ func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to set event: %v", err)
 	}
 
-	return ctx.GetStub().PutState(id, valueJSON)
+	if err := putAssetState(ctx, id, valueJSON); err != nil {
+		return err
+	}
+
+	if hadProposal {
+		return setAssetEndorsers(ctx, id, value.Asset.OwnerID)
+	}
+	return nil
+}
+
+// moveAssetDetails moves the asset's details between the public state and the owner's private
+// collection so that they match newView
+func (s *SmartContract) moveAssetDetails(ctx contractapi.TransactionContextInterface, asset *Asset, newView string) error {
+	if newView == PublicView {
+		return makeAssetPublic(ctx, asset)
+	}
+	if asset.PrivateDataHash != EmptyTxt {
+		return nil
+	}
+
+	salt := EmptyTxt
+	details, err := readTransientDetails(ctx)
+	if err != nil {
+		return err
+	}
+	if details != nil {
+		salt = details.Salt
+	}
+	return makeAssetPrivate(ctx, asset, salt)
 }
 
-// AssetExists returns true when asset with given ID exists in world state
+// AssetExists returns true when asset with given ID exists in world state, including assets
+// still stored under a bare key (see MigrateAssetKeys)
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
-	assetJSON, err := ctx.GetStub().GetState(id)
+	assetJSON, err := getAssetState(ctx, id)
 	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
+		return false, prefixError(err, "failed to read from world state")
 	}
-
 	return assetJSON != nil, nil
 }
 
-// GetAssetHistory returns the provenance of an asset
+// GetAssetHistory returns the provenance of an asset to its owner, proposed owner, admins and auditors
 func (s *SmartContract) GetAssetHistory(ctx contractapi.TransactionContextInterface, id string) ([]HistoryRecord, error) {
-	historyIterator, err := ctx.GetStub().GetHistoryForKey(id)
+	if !hasRole(ctx, AdminRole, AuditorRole) {
+		value, err := s.ReadAsset(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		clientFullID, err := s.getClientFullIdentifier(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if value.Asset.OwnerID != clientFullID && value.Asset.ProposedOwnerID != clientFullID {
+			return nil, newError(PermissionDenied, "provenance history is restricted to the owner, proposed owner, admins and auditors")
+		}
+	}
+
+	key, err := assetKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assets created before keys were namespaced keep their earlier history under the bare key
+	records, err := readAssetHistory(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	current, err := readAssetHistory(ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(records, current...), nil
+}
+
+// readAssetHistory converts the history of one state key into history records. On a legacy bare
+// key, deletions are skipped: the contract never deletes assets, so they mark MigrateAssetKeys.
+func readAssetHistory(ctx contractapi.TransactionContextInterface, key string, legacyKey bool) ([]HistoryRecord, error) {
+	historyIterator, err := ctx.GetStub().GetHistoryForKey(key)
 	if err != nil {
 		return nil, err
 	}
 	defer historyIterator.Close()
 
-	var records []HistoryRecord
+	records := []HistoryRecord{}
 	for historyIterator.HasNext() {
 		response, err := historyIterator.Next()
 		if err != nil {
 			return nil, err
 		}
+		if legacyKey && response.IsDelete {
+			continue
+		}
 
 		var value LedgerValue
 		if len(response.Value) > 0 {
-			var temp map[string]interface{}
-			json.Unmarshal(response.Value, &temp)
-			
-			if _, ok := temp["asset"]; ok || temp["Asset"] != nil {
-				json.Unmarshal(response.Value, &value)
-			} else {
-				// Legacy data handling
-				var asset Asset
-				json.Unmarshal(response.Value, &asset)
-				value.Asset = asset
-				value.Audit = AuditMetadata{Action: "LEGACY", Actor: "Unknown"}
+			if value, err = storedLedgerValue(response.Value); err != nil {
+				return nil, err
 			}
 		}
 
@@ -505,32 +807,94 @@ func (s *SmartContract) GetAssetHistory(ctx contractapi.TransactionContextInterf
 
 // GetAllAssets returns all assets found in world state
 func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]*LedgerValue, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(AssetObjectType, []string{})
 	if err != nil {
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	var values []*LedgerValue
+	return readLedgerValues(resultsIterator)
+}
+
+// GetAssetsPaginated returns one page of assets from a composite key range query along with the bookmark for the next page
+func (s *SmartContract) GetAssetsPaginated(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	if pageSize <= 0 {
+		return nil, newError(InvalidArgument, "page size must be greater than zero")
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(AssetObjectType, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	values, err := readLedgerValues(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedQueryResult{
+		Records:             values,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
+
+// QueryAssetsPaginated runs a CouchDB rich query filtered by owner, status and view (empty filters are ignored)
+func (s *SmartContract) QueryAssetsPaginated(ctx contractapi.TransactionContextInterface, ownerID string, status string, view string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	if pageSize <= 0 {
+		return nil, newError(InvalidArgument, "page size must be greater than zero")
+	}
+
+	selector := map[string]interface{}{
+		"asset": map[string]interface{}{"$exists": true},
+	}
+	if ownerID != EmptyTxt {
+		selector["asset.ownerId"] = ownerID
+	}
+	if status != EmptyTxt {
+		selector["asset.status"] = status
+	}
+	if view != EmptyTxt {
+		selector["asset.view"] = view
+	}
+
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	values, err := readLedgerValues(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedQueryResult{
+		Records:             values,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
+
+// readLedgerValues drains a state iterator into ledger values, wrapping legacy flat assets
+func readLedgerValues(resultsIterator shim.StateQueryIteratorInterface) ([]*LedgerValue, error) {
+	values := []*LedgerValue{}
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var value LedgerValue
-		var temp map[string]interface{}
-		json.Unmarshal(queryResponse.Value, &temp)
-		
-		if _, ok := temp["asset"]; ok || temp["Asset"] != nil {
-			json.Unmarshal(queryResponse.Value, &value)
-		} else {
-			var asset Asset
-			json.Unmarshal(queryResponse.Value, &asset)
-			value.Asset = asset
-			value.Audit = AuditMetadata{Action: "LEGACY", Actor: "Unknown"}
+		value, err := storedLedgerValue(queryResponse.Value)
+		if err != nil {
+			return nil, err
 		}
-		
 		values = append(values, &value)
 	}
 
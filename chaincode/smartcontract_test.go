@@ -0,0 +1,463 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const (
+	alice = "Org1MSP::alice"
+	bob   = "Org2MSP::bob"
+	carol = "Org1MSP::carol"
+)
+
+func createPublicAsset(t *testing.T, l *testLedger, id string) {
+	t.Helper()
+	ctx := l.as("Org1MSP", "alice")
+	if _, err := l.contract.CreateAsset(ctx, id, "Painting", "Oil on canvas", "https://img/1", "imghash", PublicView,
+		"deed.pdf", 42, "filehash", "bafy", "assets/deed.pdf", "minio"); err != nil {
+		t.Fatalf("CreateAsset(%s): %v", id, err)
+	}
+}
+
+// stateKey returns the composite world state key of an asset
+func stateKey(id string) string {
+	key, err := shim.CreateCompositeKey(AssetObjectType, []string{id})
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func storedValue(t *testing.T, l *testLedger, id string) LedgerValue {
+	t.Helper()
+	var value LedgerValue
+	if err := json.Unmarshal(l.stub.state[stateKey(id)], &value); err != nil {
+		t.Fatalf("stored value for %s: %v", id, err)
+	}
+	return value
+}
+
+func expectError(t *testing.T, err error, contains string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error containing %q, got nil", contains)
+	}
+	if !strings.Contains(err.Error(), contains) {
+		t.Fatalf("expected error containing %q, got %q", contains, err.Error())
+	}
+}
+
+func TestInitLedger(t *testing.T) {
+	l := newTestLedger()
+	if err := l.contract.InitLedger(l.as("Org1MSP", "admin")); err != nil {
+		t.Fatalf("InitLedger: %v", err)
+	}
+
+	value := storedValue(t, l, "asset1")
+	if value.Asset.OwnerID != "Org1MSP::admin" || value.Asset.Status != ActiveStatus || value.Asset.View != PublicView {
+		t.Fatalf("unexpected genesis asset: %+v", value.Asset)
+	}
+	if value.Audit.Action != InitActionType || value.Audit.Actor != InitActorID {
+		t.Fatalf("unexpected genesis audit: %+v", value.Audit)
+	}
+	if value.Audit.Timestamp != l.stub.txTimestamp.Format(time.RFC3339) {
+		t.Fatalf("audit timestamp %s does not match tx time", value.Audit.Timestamp)
+	}
+}
+
+func TestCreateAsset(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	value := storedValue(t, l, "a1")
+	if value.Asset.OwnerID != alice {
+		t.Fatalf("owner = %s, want %s", value.Asset.OwnerID, alice)
+	}
+	if value.Asset.Status != ActiveStatus || value.Asset.Name != "Painting" || len(value.Asset.Attachments) != 1 || value.Asset.Attachments[0].FileSize != 42 {
+		t.Fatalf("unexpected asset: %+v", value.Asset)
+	}
+	if value.Audit.Action != CreateActionType || value.Audit.Actor != alice {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+	if event := l.stub.lastEvent(); event.Name != "CreateAsset" {
+		t.Fatalf("event = %q, want CreateAsset", event.Name)
+	}
+
+	_, err := l.contract.CreateAsset(l.as("Org2MSP", "bob"), "a1", "Other", "", "", "", PublicView, "", 0, "", "", "", "")
+	expectError(t, err, "already exists")
+}
+
+func TestCreateAssetFallsBackToCommonName(t *testing.T) {
+	l := newTestLedger()
+	ctx := l.withIdentity(&fakeIdentity{mspid: "Org1MSP", commonName: "dave", attrs: map[string]string{}})
+	if _, err := l.contract.CreateAsset(ctx, "a1", "n", "d", "", "", PublicView, "", 0, "", "", "", ""); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+	if owner := storedValue(t, l, "a1").Asset.OwnerID; owner != "Org1MSP::dave" {
+		t.Fatalf("owner = %s, want Org1MSP::dave", owner)
+	}
+
+	ctx = l.withIdentity(&fakeIdentity{mspid: "Org1MSP", attrs: map[string]string{}})
+	if _, err := l.contract.CreateAsset(ctx, "a2", "n", "d", "", "", PublicView, "", 0, "", "", "", ""); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+	if owner := storedValue(t, l, "a2").Asset.OwnerID; owner != "Org1MSP::unknown_identity" {
+		t.Fatalf("owner = %s, want Org1MSP::unknown_identity", owner)
+	}
+}
+
+func TestReadAsset(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	value, err := l.contract.ReadAsset(l.as("Org2MSP", "bob"), "a1")
+	if err != nil {
+		t.Fatalf("ReadAsset: %v", err)
+	}
+	if value.Asset.ID != "a1" || value.Audit.Action != CreateActionType {
+		t.Fatalf("unexpected value: %+v", value)
+	}
+
+	_, err = l.contract.ReadAsset(l.as("Org1MSP", "alice"), "missing")
+	expectError(t, err, "does not exist")
+}
+
+func TestReadAssetLegacyFormat(t *testing.T) {
+	l := newTestLedger()
+	l.stub.state[stateKey("legacy")] = []byte(`{"ID":"legacy","name":"Old","ownerId":"Org1MSP::alice","status":"ACTIVE","view":"PUBLIC"}`)
+
+	value, err := l.contract.ReadAsset(l.as("Org1MSP", "alice"), "legacy")
+	if err != nil {
+		t.Fatalf("ReadAsset: %v", err)
+	}
+	if value.Asset.Name != "Old" || value.Audit.Action != "LEGACY" || value.Audit.Actor != "Unknown" {
+		t.Fatalf("unexpected legacy value: %+v", value)
+	}
+}
+
+func TestProposeAndAcceptTransfer(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	value := storedValue(t, l, "a1")
+	if value.Asset.Status != PendingTransferStatus || value.Asset.ProposedOwnerID != bob {
+		t.Fatalf("unexpected proposed asset: %+v", value.Asset)
+	}
+	if value.Audit.Action != TransferProposeActionType || value.Audit.Actor != alice {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+
+	expectError(t, l.contract.AcceptTransfer(l.as("Org1MSP", "carol"), "a1"), "not the proposed owner")
+
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a1"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+	value = storedValue(t, l, "a1")
+	if value.Asset.OwnerID != bob || value.Asset.ProposedOwnerID != "" || value.Asset.Status != ActiveStatus {
+		t.Fatalf("unexpected accepted asset: %+v", value.Asset)
+	}
+	if value.Audit.Action != TransferAcceptActionType || value.Audit.Actor != bob {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+	if event := l.stub.lastEvent(); event.Name != "AcceptTransfer" {
+		t.Fatalf("event = %q, want AcceptTransfer", event.Name)
+	}
+
+	expectError(t, l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a1"), "not in PENDING_TRANSFER")
+}
+
+func TestProposeTransferValidation(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	expectError(t, l.contract.ProposeTransfer(l.as("Org2MSP", "bob"), "a1", bob, ""), "only the owner")
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", "", ""), "invalid transfer recipient")
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", alice, ""), "invalid transfer recipient")
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, "tomorrow"), "invalid expiry")
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, "2000-01-01T00:00:00Z"), "must be in the future")
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "missing", bob, ""), "does not exist")
+
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", carol, ""), "illegal status transition PENDING_TRANSFER -> PENDING_TRANSFER")
+}
+
+func TestRejectTransfer(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+
+	expectError(t, l.contract.RejectTransfer(l.as("Org1MSP", "alice"), "a1"), "only the proposed owner")
+	if err := l.contract.RejectTransfer(l.as("Org2MSP", "bob"), "a1"); err != nil {
+		t.Fatalf("RejectTransfer: %v", err)
+	}
+
+	value := storedValue(t, l, "a1")
+	if value.Asset.OwnerID != alice || value.Asset.ProposedOwnerID != "" || value.Asset.Status != ActiveStatus {
+		t.Fatalf("unexpected rejected asset: %+v", value.Asset)
+	}
+	if value.Audit.Action != TransferRejectActionType || value.Audit.Actor != bob {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+	expectError(t, l.contract.RejectTransfer(l.as("Org2MSP", "bob"), "a1"), "not in PENDING_TRANSFER")
+}
+
+func TestCancelTransfer(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+
+	expectError(t, l.contract.CancelTransfer(l.as("Org2MSP", "bob"), "a1"), "only the owner")
+	if err := l.contract.CancelTransfer(l.as("Org1MSP", "alice"), "a1"); err != nil {
+		t.Fatalf("CancelTransfer: %v", err)
+	}
+
+	value := storedValue(t, l, "a1")
+	if value.Asset.OwnerID != alice || value.Asset.Status != ActiveStatus || value.Audit.Action != TransferCancelActionType {
+		t.Fatalf("unexpected cancelled value: %+v", value)
+	}
+	if event := l.stub.lastEvent(); event.Name != "CancelTransfer" {
+		t.Fatalf("event = %q, want CancelTransfer", event.Name)
+	}
+}
+
+func TestTransferExpiry(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	// Each fake transaction advances the clock by a minute, so this proposal lapses on the third tx after creation
+	expiresAt := l.stub.txTimestamp.Add(3 * time.Minute).Format(time.RFC3339)
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, expiresAt); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if got := storedValue(t, l, "a1").Asset.TransferExpiresAt; got != expiresAt {
+		t.Fatalf("transferExpiresAt = %s, want %s", got, expiresAt)
+	}
+
+	expectError(t, l.contract.ExpireTransfer(l.as("Org1MSP", "carol"), "a1"), "has not expired")
+	expectError(t, l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a1"), "expired")
+
+	if err := l.contract.ExpireTransfer(l.as("Org1MSP", "carol"), "a1"); err != nil {
+		t.Fatalf("ExpireTransfer: %v", err)
+	}
+	value := storedValue(t, l, "a1")
+	if value.Asset.OwnerID != alice || value.Asset.Status != ActiveStatus || value.Asset.TransferExpiresAt != "" {
+		t.Fatalf("unexpected expired asset: %+v", value.Asset)
+	}
+	if value.Audit.Action != TransferExpireActionType || value.Audit.Actor != carol {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+}
+
+func TestUpdateAssetStatus(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	// Org membership alone no longer grants admin rights; the role attribute does
+	expectError(t, l.contract.UpdateAssetStatus(l.as("Org1MSP", "alice"), "a1", FrozenStatus), "administrative access required")
+	expectError(t, l.contract.UpdateAssetStatus(l.as("Org2MSP", "eve", RoleAttribute, AuditorRole), "a1", FrozenStatus), "requires role=admin")
+	expectError(t, l.contract.UpdateAssetStatus(l.as("Org2MSP", "bob", "admin", "true"), "a1", FrozenStatus), "administrative access required")
+
+	if err := l.contract.UpdateAssetStatus(l.as("Org3MSP", "ops", RoleAttribute, AdminRole), "a1", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus with admin role: %v", err)
+	}
+	value := storedValue(t, l, "a1")
+	if value.Asset.Status != FrozenStatus {
+		t.Fatalf("status = %s, want %s", value.Asset.Status, FrozenStatus)
+	}
+	if value.Audit.Action != UpdateStatusActionType || value.Audit.Actor != "Org3MSP::ops_ADMIN" {
+		t.Fatalf("unexpected audit: %+v", value.Audit)
+	}
+
+	expectError(t, l.contract.UpdateAssetStatus(l.asAdmin(), "missing", FrozenStatus), "does not exist")
+}
+
+func TestUpdateAssetView(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	expectError(t, l.contract.UpdateAssetView(l.as("Org2MSP", "bob"), "a1", PrivateView), "only the owner")
+	expectError(t, l.contract.UpdateAssetView(l.as("Org1MSP", "alice"), "a1", "SECRET"), "invalid view")
+
+	if err := l.contract.UpdateAssetView(salted(l, l.as("Org1MSP", "alice")), "a1", PrivateView); err != nil {
+		t.Fatalf("UpdateAssetView: %v", err)
+	}
+	value := storedValue(t, l, "a1")
+	if value.Asset.View != PrivateView || value.Audit.Action != UpdateViewActionType {
+		t.Fatalf("unexpected value: %+v", value)
+	}
+	if event := l.stub.lastEvent(); event.Name != "UpdateAssetView" {
+		t.Fatalf("event = %q, want UpdateAssetView", event.Name)
+	}
+}
+
+func TestDeleteAsset(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	expectError(t, l.contract.DeleteAsset(l.as("Org2MSP", "bob"), "a1"), "only the owner")
+	if err := l.contract.DeleteAsset(salted(l, l.as("Org1MSP", "alice")), "a1"); err != nil {
+		t.Fatalf("DeleteAsset: %v", err)
+	}
+
+	value := storedValue(t, l, "a1")
+	if value.Asset.Status != DeletedStatus || value.Asset.View != PrivateView || value.Audit.Action != DeleteActionType {
+		t.Fatalf("unexpected deleted value: %+v", value)
+	}
+	// Soft delete keeps the key so history and audits remain readable
+	exists, err := l.contract.AssetExists(l.as("Org1MSP", "alice"), "a1")
+	if err != nil || !exists {
+		t.Fatalf("AssetExists after soft delete = %v, %v", exists, err)
+	}
+}
+
+func TestAssetExists(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	l.stub.state["legacy"] = []byte(`{"ID":"legacy","ownerId":"Org1MSP::alice"}`)
+
+	for id, want := range map[string]bool{"a1": true, "legacy": true, "missing": false} {
+		got, err := l.contract.AssetExists(l.as("Org1MSP", "alice"), id)
+		if err != nil {
+			t.Fatalf("AssetExists(%s): %v", id, err)
+		}
+		if got != want {
+			t.Fatalf("AssetExists(%s) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestGetAssetHistory(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a1"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+	l.stub.DelState(stateKey("a1"))
+
+	records, err := l.contract.GetAssetHistory(l.as("Org2MSP", "eve", RoleAttribute, AuditorRole), "a1")
+	if err != nil {
+		t.Fatalf("GetAssetHistory: %v", err)
+	}
+
+	want := []struct{ action, actor string }{
+		{CreateActionType, alice},
+		{TransferProposeActionType, alice},
+		{TransferAcceptActionType, bob},
+		{DeleteActionType, ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d history records, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		if records[i].ActionType != w.action || records[i].ActorID != w.actor {
+			t.Fatalf("record %d = %s by %q, want %s by %q", i, records[i].ActionType, records[i].ActorID, w.action, w.actor)
+		}
+	}
+	if !records[3].IsDelete || records[0].TxId == "" || records[2].Value.OwnerID != bob {
+		t.Fatalf("unexpected history records: %+v", records)
+	}
+}
+
+func TestGetAllAssets(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	createPublicAsset(t, l, "a2")
+	l.stub.state[stateKey("legacy")] = []byte(`{"ID":"legacy","ownerId":"Org1MSP::alice"}`)
+
+	values, err := l.contract.GetAllAssets(l.as("Org1MSP", "alice"))
+	if err != nil {
+		t.Fatalf("GetAllAssets: %v", err)
+	}
+	if len(values) != 3 {
+		t.Fatalf("got %d assets, want 3", len(values))
+	}
+	if values[2].Asset.ID != "legacy" || values[2].Audit.Action != "LEGACY" {
+		t.Fatalf("legacy asset not wrapped: %+v", values[2])
+	}
+}
+
+func TestGetAssetsPaginated(t *testing.T) {
+	l := newTestLedger()
+	for _, id := range []string{"a1", "a2", "a3"} {
+		createPublicAsset(t, l, id)
+	}
+
+	_, err := l.contract.GetAssetsPaginated(l.as("Org1MSP", "alice"), 0, "")
+	expectError(t, err, "page size")
+
+	page, err := l.contract.GetAssetsPaginated(l.as("Org1MSP", "alice"), 2, "")
+	if err != nil {
+		t.Fatalf("GetAssetsPaginated: %v", err)
+	}
+	if page.FetchedRecordsCount != 2 || len(page.Records) != 2 || page.Bookmark == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+
+	page, err = l.contract.GetAssetsPaginated(l.as("Org1MSP", "alice"), 2, page.Bookmark)
+	if err != nil {
+		t.Fatalf("GetAssetsPaginated: %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Asset.ID != "a3" || page.Bookmark != "" {
+		t.Fatalf("unexpected last page: %+v", page)
+	}
+}
+
+func TestQueryAssetsPaginated(t *testing.T) {
+	l := newTestLedger()
+	for _, id := range []string{"a1", "a2", "a3"} {
+		createPublicAsset(t, l, id)
+	}
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a2", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a3", bob, ""); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	if err := l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "a3"); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+
+	_, err := l.contract.QueryAssetsPaginated(l.as("Org1MSP", "alice"), "", "", "", -1, "")
+	expectError(t, err, "page size")
+
+	tests := []struct {
+		owner, status, view string
+		want                []string
+	}{
+		{"", "", "", []string{"a1", "a2", "a3"}},
+		{alice, "", "", []string{"a1", "a2"}},
+		{"", FrozenStatus, "", []string{"a2"}},
+		{bob, ActiveStatus, PublicView, []string{"a3"}},
+		{"", "", PrivateView, nil},
+	}
+	for _, tt := range tests {
+		page, err := l.contract.QueryAssetsPaginated(l.as("Org1MSP", "alice"), tt.owner, tt.status, tt.view, 10, "")
+		if err != nil {
+			t.Fatalf("QueryAssetsPaginated(%q, %q, %q): %v", tt.owner, tt.status, tt.view, err)
+		}
+		var got []string
+		for _, record := range page.Records {
+			got = append(got, record.Asset.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Fatalf("QueryAssetsPaginated(%q, %q, %q) = %v, want %v", tt.owner, tt.status, tt.view, got, tt.want)
+		}
+	}
+}
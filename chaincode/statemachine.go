@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// assetTransitions declares every legal status change. DELETED is terminal.
+var assetTransitions = map[string][]string{
+	ActiveStatus:          {FrozenStatus, PendingTransferStatus, DeletedStatus},
+	FrozenStatus:          {ActiveStatus, DeletedStatus},
+	PendingTransferStatus: {ActiveStatus, DeletedStatus},
+	DeletedStatus:         {},
+}
+
+// checkStatusTransition returns an error naming the transition when from -> to is not in assetTransitions
+func checkStatusTransition(from string, to string) error {
+	if _, known := assetTransitions[to]; !known {
+		return newError(InvalidArgument, "unknown asset status %s", to)
+	}
+	allowed, known := assetTransitions[from]
+	if !known {
+		return newError(Conflict, "illegal status transition %s -> %s: unknown current status", from, to)
+	}
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+	return newError(Conflict, "illegal status transition %s -> %s", from, to)
+}
+
+// transitionStatus moves the asset to a new status after checking the transition table
+func transitionStatus(asset *Asset, to string) error {
+	if err := checkStatusTransition(asset.Status, to); err != nil {
+		return prefixError(err, "asset %s", asset.ID)
+	}
+	asset.Status = to
+	return nil
+}
+
+// requireActive guards owner edits that leave the status unchanged
+func requireActive(asset Asset) error {
+	if asset.Status != ActiveStatus {
+		return newError(Conflict, "asset %s is %s; only ACTIVE assets can be modified", asset.ID, asset.Status)
+	}
+	return nil
+}
+
+// GetAllowedTransitions returns the statuses the asset can move to from its current status
+func (s *SmartContract) GetAllowedTransitions(ctx contractapi.TransactionContextInterface, id string) ([]string, error) {
+	value, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []string{}
+	allowed = append(allowed, assetTransitions[value.Asset.Status]...)
+	return allowed, nil
+}
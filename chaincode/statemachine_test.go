@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckStatusTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  string
+	}{
+		{ActiveStatus, FrozenStatus, ""},
+		{FrozenStatus, ActiveStatus, ""},
+		{ActiveStatus, PendingTransferStatus, ""},
+		{PendingTransferStatus, ActiveStatus, ""},
+		{ActiveStatus, DeletedStatus, ""},
+		{FrozenStatus, DeletedStatus, ""},
+		{PendingTransferStatus, DeletedStatus, ""},
+		{FrozenStatus, PendingTransferStatus, "illegal status transition FROZEN -> PENDING_TRANSFER"},
+		{PendingTransferStatus, FrozenStatus, "illegal status transition PENDING_TRANSFER -> FROZEN"},
+		{DeletedStatus, ActiveStatus, "illegal status transition DELETED -> ACTIVE"},
+		{ActiveStatus, ActiveStatus, "illegal status transition ACTIVE -> ACTIVE"},
+		{ActiveStatus, "ARCHIVED", "unknown asset status ARCHIVED"},
+		{"", ActiveStatus, "unknown current status"},
+	}
+	for _, tt := range tests {
+		err := checkStatusTransition(tt.from, tt.to)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("%s -> %s: unexpected error %v", tt.from, tt.to, err)
+			}
+			continue
+		}
+		expectError(t, err, tt.wantErr)
+	}
+}
+
+func TestGetAllowedTransitions(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	check := func(want ...string) {
+		t.Helper()
+		got, err := l.contract.GetAllowedTransitions(l.as("Org1MSP", "alice"), "a1")
+		if err != nil {
+			t.Fatalf("GetAllowedTransitions: %v", err)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("allowed transitions = %v, want %v", got, want)
+		}
+	}
+
+	check(FrozenStatus, PendingTransferStatus, DeletedStatus)
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a1", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	check(ActiveStatus, DeletedStatus)
+	if err := l.contract.DeleteAsset(salted(l, l.as("Org1MSP", "alice")), "a1"); err != nil {
+		t.Fatalf("DeleteAsset: %v", err)
+	}
+	check()
+
+	_, err := l.contract.GetAllowedTransitions(l.as("Org1MSP", "alice"), "missing")
+	expectError(t, err, "does not exist")
+}
+
+func TestUpdateAssetStatusEnforcesTransitions(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+
+	expectError(t, l.contract.UpdateAssetStatus(l.asAdmin(), "a1", "ARCHIVED"), "unknown asset status ARCHIVED")
+	expectError(t, l.contract.UpdateAssetStatus(l.asAdmin(), "a1", PendingTransferStatus), "use ProposeTransfer")
+
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a1", DeletedStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus to DELETED: %v", err)
+	}
+	expectError(t, l.contract.UpdateAssetStatus(l.asAdmin(), "a1", ActiveStatus), "illegal status transition DELETED -> ACTIVE")
+	if status := storedValue(t, l, "a1").Asset.Status; status != DeletedStatus {
+		t.Fatalf("status = %s, want %s", status, DeletedStatus)
+	}
+}
+
+func TestFrozenAssetCannotBeTransferred(t *testing.T) {
+	l := newTestLedger()
+	createPublicAsset(t, l, "a1")
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a1", FrozenStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+
+	expectError(t, l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), "a1", bob, ""), "illegal status transition FROZEN -> PENDING_TRANSFER")
+	expectError(t, l.contract.UpdateAssetView(l.as("Org1MSP", "alice"), "a1", PrivateView), "only ACTIVE assets can be modified")
+}
+
+func TestLeavingPendingTransferWithdrawsProposal(t *testing.T) {
+	l := newTestLedger()
+	createPrivateAsset(t, l, "p1")
+	createPublicAsset(t, l, "a1")
+	for _, id := range []string{"p1", "a1"} {
+		if err := l.contract.ProposeTransfer(l.as("Org1MSP", "alice"), id, bob, ""); err != nil {
+			t.Fatalf("ProposeTransfer(%s): %v", id, err)
+		}
+	}
+
+	expectError(t, l.contract.UpdateAssetStatus(l.asAdmin(), "a1", FrozenStatus), "illegal status transition PENDING_TRANSFER -> FROZEN")
+
+	if err := l.contract.UpdateAssetStatus(l.asAdmin(), "a1", ActiveStatus); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+	asset := storedValue(t, l, "a1").Asset
+	if asset.Status != ActiveStatus || asset.ProposedOwnerID != "" || asset.OwnerID != alice {
+		t.Fatalf("proposal not withdrawn: %+v", asset)
+	}
+	if got := assetEndorsers(t, l, "a1"); got != "Org1MSP" {
+		t.Fatalf("endorsers after release = %q, want Org1MSP", got)
+	}
+
+	if err := l.contract.DeleteAsset(l.as("Org1MSP", "alice"), "p1"); err != nil {
+		t.Fatalf("DeleteAsset: %v", err)
+	}
+	asset = storedValue(t, l, "p1").Asset
+	if asset.Status != DeletedStatus || asset.ProposedOwnerID != "" {
+		t.Fatalf("proposal not withdrawn on delete: %+v", asset)
+	}
+	if l.stub.private[implicitCollection("Org2MSP")]["p1"] != nil {
+		t.Fatalf("recipient org still holds the details after delete")
+	}
+	expectError(t, l.contract.AcceptTransfer(l.as("Org2MSP", "bob"), "p1"), "not in PENDING_TRANSFER")
+	expectError(t, l.contract.DeleteAsset(l.as("Org1MSP", "alice"), "p1"), "illegal status transition DELETED -> DELETED")
+}
@@ -58,23 +58,58 @@ export const fetchAssets = async () => {
     return response.data;
 };
 
+// Ledger-backed listing (admins): returns { assets, bookmark, fetched_count }
+export const fetchAssetPage = async ({ limit = 50, bookmark, owner, status, view } = {}) => {
+    const response = await api.get('/assets', { params: { limit, bookmark, owner, status, view } });
+    return response.data;
+};
+
 export const fetchAssetById = async (id) => {
     const response = await api.get(`/assets/${id}`);
     return response.data;
 };
 
+// Statuses the asset may move to next, from the chaincode's transition table
+export const fetchAllowedTransitions = async (id) => {
+    const response = await api.get(`/assets/${id}/transitions`);
+    return response.data.transitions;
+};
+
+// Details of a PRIVATE asset (owner / proposed owner only), read from the org's private collection
+export const fetchPrivateDetails = async (id) => {
+    const response = await api.get(`/assets/${id}/private`);
+    return response.data;
+};
+
 export const fetchBlockchainAsset = async (id) => {
     const response = await api.get(`/assets/${id}/blockchain`);
     return response.data;
 };
 
-export const createAsset = async (asset) => {
-    const response = await api.post('/assets', asset);
+// idempotencyKey makes retries of the same submission replay the first result instead of failing
+export const createAsset = async (asset, idempotencyKey) => {
+    const headers = idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : {};
+    const response = await api.post('/assets', asset, { headers });
+    return response.data;
+};
+
+export const proposeTransfer = async (id, targetUser, expiresAt) => {
+    const response = await api.post(`/assets/${id}/transfer`, { target_user: targetUser, expires_at: expiresAt || '' });
     return response.data;
 };
 
-export const proposeTransfer = async (id, targetUser) => {
-    const response = await api.post(`/assets/${id}/transfer`, { target_user: targetUser });
+export const rejectTransfer = async (id) => {
+    const response = await api.post(`/assets/${id}/reject`);
+    return response.data;
+};
+
+export const cancelTransfer = async (id) => {
+    const response = await api.post(`/assets/${id}/cancel`);
+    return response.data;
+};
+
+export const expireTransfer = async (id) => {
+    const response = await api.post(`/assets/${id}/expire`);
     return response.data;
 };
 
@@ -83,6 +118,28 @@ export const acceptTransfer = async (id) => {
     return response.data;
 };
 
+// metadata holds only the fields to change: name, desc, image_url, image_hash, attachments
+export const updateAssetMetadata = async (id, metadata) => {
+    const response = await api.patch(`/assets/${id}`, metadata);
+    return response.data;
+};
+
+export const addAttachment = async (id, attachment) => {
+    const response = await api.post(`/assets/${id}/attachments`, attachment);
+    return response.data;
+};
+
+// index is the attachment's position on the ledger; currentHash guards against a stale list
+export const replaceAttachment = async (id, index, currentHash, attachment) => {
+    const response = await api.put(`/assets/${id}/attachments/${index}`, { current_hash: currentHash, attachment });
+    return response.data;
+};
+
+export const removeAttachment = async (id, index, currentHash) => {
+    const response = await api.delete(`/assets/${id}/attachments/${index}`, { params: { current_hash: currentHash } });
+    return response.data;
+};
+
 export const updateAssetView = async (id, view) => {
     const response = await api.post(`/assets/${id}/view`, { view });
     return response.data;
@@ -113,17 +170,52 @@ export const deleteAccount = async () => {
     return response.data;
 };
 
+// Files up to this size are hashed in the browser so the backend can reject a corrupted upload;
+// larger ones are hashed by the backend alone
+const CLIENT_HASH_LIMIT = 256 * 1024 * 1024;
+
+const sha256Hex = async (file) => {
+    const digest = await crypto.subtle.digest('SHA-256', await file.arrayBuffer());
+    return Array.from(new Uint8Array(digest)).map(b => b.toString(16).padStart(2, '0')).join('');
+};
+
+// Uploads straight to MinIO with a presigned URL, then has the backend verify the object, pin it
+// to IPFS and return its AssetAttachment
 export const uploadToStorage = async (file) => {
-    const formData = new FormData();
-    formData.append('file', file);
-    const response = await api.post('/api/storage/upload', formData, {
-        headers: {
-            'Content-Type': 'multipart/form-data',
-        },
+    const contentType = file.type || 'application/octet-stream';
+    const fileHash = file.size <= CLIENT_HASH_LIMIT ? await sha256Hex(file) : '';
+
+    const { data: presigned } = await api.post('/api/storage/presign', {
+        file_name: file.name,
+        file_size: file.size,
+        content_type: contentType,
+    });
+
+    const upload = await fetch(presigned.url, {
+        method: 'PUT',
+        headers: presigned.headers,
+        body: file,
+    });
+    if (!upload.ok) {
+        throw new Error(`Direct upload failed (${upload.status})`);
+    }
+
+    const response = await api.post('/api/storage/complete', {
+        object_name: presigned.object_name,
+        file_name: file.name,
+        file_hash: fileHash,
     });
     return response.data; // AssetAttachment object
 };
 
+export const uploadManyToStorage = async (files) => {
+    const attachments = [];
+    for (const file of files) {
+        attachments.push(await uploadToStorage(file));
+    }
+    return attachments; // AssetAttachment objects, in upload order
+};
+
 export const fetchStorageURL = async (objectName, download = false) => {
     const response = await api.get(`/api/storage/url/${objectName}${download ? '?download=true' : ''}`);
     return response.data.url;
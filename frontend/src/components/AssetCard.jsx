@@ -7,7 +7,7 @@ import { Link } from 'react-router-dom';
 const AssetCard = ({ asset }) => {
     const isPending = asset.status === 'PENDING_TRANSFER';
     const isDeleted = asset.status === 'DELETED';
-    const hasAttachment = asset.attachment && asset.attachment.file_name;
+    const hasAttachment = asset.attachments && asset.attachments.length > 0;
 
     const [displayUrl, setDisplayUrl] = React.useState('');
 
@@ -4,7 +4,7 @@ import { Globe, Clock, User, Paperclip } from 'lucide-react';
 import { Link } from 'react-router-dom';
 
 const GalleryAssetCard = ({ asset }) => {
-    const hasAttachment = asset.attachment && asset.attachment.file_name;
+    const hasAttachment = asset.attachments && asset.attachments.length > 0;
     const [displayUrl, setDisplayUrl] = React.useState('');
 
     React.useEffect(() => {
@@ -23,6 +23,7 @@ const AdminAssets = () => {
     const [loading, setLoading] = useState(true);
     const [syncing, setSyncing] = useState(false);
     const [note, setNote] = useState('');
+    const [bookmark, setBookmark] = useState('');
     const [currentPage, setCurrentPage] = useState(1);
     const itemsPerPage = 8;
 
@@ -31,13 +32,17 @@ const AdminAssets = () => {
         fetchData();
     }, [source, token]);
 
-    const fetchData = async () => {
+    const fetchData = async (nextBookmark = '') => {
         setLoading(true);
         try {
-            const res = await api.get(`/admin/assets?source=${source}`, {
+            const res = await api.get('/admin/assets', {
+                params: { source, limit: 50, bookmark: nextBookmark || undefined },
                 headers: { Authorization: `Bearer ${token}` }
             });
-            setAssets(res.data.assets || []);
+            const page = res.data.assets || [];
+            // Ledger pages are appended; a fresh fetch starts over
+            setAssets(prev => nextBookmark ? [...prev, ...page] : page);
+            setBookmark(source === 'blockchain' && page.length > 0 ? (res.data.bookmark || '') : '');
             setNote(res.data.note || '');
         } catch (err) {
             console.error("Failed to fetch admin assets", err);
@@ -188,7 +193,7 @@ const AdminAssets = () => {
                                     </td>
                                     <td className="px-6 py-4">
                                         <div className="flex justify-end items-center gap-3">
-                                            {asset.status !== 'FROZEN' && asset.status !== 'DELETED' && (
+                                            {asset.status === 'ACTIVE' && (
                                                 <button
                                                     onClick={() => handleUpdateStatus(asset.ID, 'FROZEN')}
                                                     disabled={syncing}
@@ -236,6 +241,17 @@ const AdminAssets = () => {
                         totalPages={totalPages}
                         onPageChange={setCurrentPage}
                     />
+                    {bookmark && (
+                        <div className="flex justify-center pb-4">
+                            <button
+                                onClick={() => fetchData(bookmark)}
+                                disabled={loading}
+                                className="px-4 py-2 rounded-lg text-[10px] font-bold uppercase tracking-widest border border-ink-800/20 text-ink-800 hover:bg-ink-800 hover:text-parchment-100 transition-all disabled:opacity-50"
+                            >
+                                Load More From Ledger
+                            </button>
+                        </div>
+                    )}
                 </div>
             )}
 
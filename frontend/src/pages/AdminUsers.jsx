@@ -126,7 +126,7 @@ const AdminUsers = () => {
                                             {id.status === 'ACTIVE' && (
                                                 <>
                                                     <button
-                                                        onClick={() => handleUpdateStatus(id.name, id.org, 'BANNED')}
+                                                        onClick={() => window.confirm(`Banning ${id.name} permanently revokes their certificate. Continue?`) && handleUpdateStatus(id.name, id.org, 'BANNED')}
                                                         disabled={actionLoading === id.name}
                                                         className="flex items-center gap-1.5 px-3 py-1 border border-red-200 text-red-600 rounded hover:bg-red-600 hover:text-white transition-all disabled:opacity-50"
                                                     >
@@ -152,14 +152,8 @@ const AdminUsers = () => {
                                                     )}
                                                 </>
                                             )}
-                                            {id.status === 'BANNED' && (
-                                                <button
-                                                    onClick={() => handleUpdateStatus(id.name, id.org, 'ACTIVE')}
-                                                    disabled={actionLoading === id.name}
-                                                    className="flex items-center gap-1.5 px-3 py-1 bg-ink-800 text-white rounded text-[9px] font-bold uppercase hover:bg-black transition-all disabled:opacity-50"
-                                                >
-                                                    <RefreshCcw size={12} /> Re-Activate
-                                                </button>
+                                            {(id.status === 'BANNED' || id.status === 'DELETED') && (
+                                                <span className="text-[9px] font-bold text-ink-800/30 italic">Certificate Revoked</span>
                                             )}
                                         </div>
                                     )}
@@ -181,9 +175,9 @@ const AdminUsers = () => {
                 <div className="space-y-1">
                     <h4 className="text-sm font-bold uppercase tracking-widest text-ink-800">Governance Security Policy</h4>
                     <p className="text-[11px] text-ink-800/70 leading-relaxed font-serif italic">
-                        Banning a user blocks application-level access immediately via PostgreSQL status checks.
-                        Root Admin identity is protected from self-modification. All status changes are logged
-                        to the off-chain audit trail for compliance verification.
+                        Banning a user revokes their enrollment certificate at the CA and publishes the new CRL to the
+                        channel, so the ban is enforced by peers as well as the API. Revocation is permanent.
+                        Root Admin identity is protected from self-modification.
                     </p>
                 </div>
             </div>
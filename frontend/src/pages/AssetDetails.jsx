@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { Link, useParams, useNavigate, useLocation } from 'react-router-dom';
-import { fetchAssets, fetchAssetById, fetchHistory, proposeTransfer, acceptTransfer, updateAssetView, deleteAsset, fetchBlockchainAsset, fetchStorageURL } from '../api/client';
-import { ArrowLeft, ArrowRight, CheckCircle, Shield, History, Eye, EyeOff, Trash2, Paperclip, ExternalLink, Link as LinkIcon, Database, Verified, FileText, Download } from 'lucide-react';
+import { fetchAssets, fetchAssetById, fetchHistory, proposeTransfer, acceptTransfer, rejectTransfer, cancelTransfer, expireTransfer, updateAssetView, updateAssetMetadata, addAttachment, replaceAttachment, removeAttachment, uploadManyToStorage, uploadToStorage, deleteAsset, fetchBlockchainAsset, fetchStorageURL, fetchPrivateDetails } from '../api/client';
+import { ArrowLeft, ArrowRight, CheckCircle, Shield, History, Eye, EyeOff, Trash2, Paperclip, ExternalLink, Link as LinkIcon, Database, Verified, FileText, Download, Upload, RefreshCw } from 'lucide-react';
 import { useAuth } from '../context/AuthContext';
 
 const AssetDetails = () => {
@@ -15,14 +15,14 @@ const AssetDetails = () => {
     const [blockchainData, setBlockchainData] = useState(null);
     const [showBlockchainModal, setShowBlockchainModal] = useState(false);
     const [displayUrl, setDisplayUrl] = useState('');
-    const [attachmentUrl, setAttachmentUrl] = useState(''); // This will be the View URL
-    const [attachmentDownloadUrl, setAttachmentDownloadUrl] = useState('');
+    const [attachmentUrls, setAttachmentUrls] = useState([]); // { view, download } per attachment
 
     const isFromAdmin = location.state?.from === 'admin';
     const userFullID = user ? `${user.org}::${user.username}` : '';
 
     // Action State
     const [transferTarget, setTransferTarget] = useState('');
+    const [editing, setEditing] = useState(null); // { name, desc } while the owner edits details
     const [actionLoading, setActionLoading] = useState(false);
 
     useEffect(() => {
@@ -31,7 +31,17 @@ const AssetDetails = () => {
 
     const loadData = async () => {
         try {
-            const [a, h] = await Promise.all([fetchAssetById(id), fetchHistory(id)]);
+            let [a, h] = await Promise.all([fetchAssetById(id), fetchHistory(id)]);
+
+            // PRIVATE assets only carry a hash on the ledger; parties to the asset fetch the details
+            if (a.privateDataHash && (a.ownerId === userFullID || a.proposedOwnerId === userFullID)) {
+                try {
+                    const details = await fetchPrivateDetails(id);
+                    a = { ...a, ...details };
+                } catch (e) {
+                    console.error("Failed to fetch private details", e);
+                }
+            }
             setAsset(a);
             setHistory(h);
 
@@ -46,17 +56,20 @@ const AssetDetails = () => {
                 }
             }
 
-            // Fetch Pre-signed URLs for Attachment (View & Download)
-            if (a.attachment?.storage_path) {
+            // Fetch Pre-signed URLs for each Attachment (View & Download)
+            const urls = await Promise.all((a.attachments || []).map(async (attachment) => {
+                if (!attachment.storage_path) return {};
                 try {
-                    const viewUrl = await fetchStorageURL(a.attachment.storage_path, false);
-                    const downloadUrl = await fetchStorageURL(a.attachment.storage_path, true);
-                    setAttachmentUrl(viewUrl);
-                    setAttachmentDownloadUrl(downloadUrl);
+                    return {
+                        view: await fetchStorageURL(attachment.storage_path, false),
+                        download: await fetchStorageURL(attachment.storage_path, true),
+                    };
                 } catch (e) {
                     console.error("Failed to fetch attachment URLs", e);
+                    return {};
                 }
-            }
+            }));
+            setAttachmentUrls(urls);
         } catch (err) {
             alert("Error loading asset");
         } finally {
@@ -90,6 +103,19 @@ const AssetDetails = () => {
         }
     };
 
+    // Reject (recipient), cancel (owner) and expire (anyone, after the deadline) all revert to ACTIVE
+    const handleRevertTransfer = async (action) => {
+        setActionLoading(true);
+        try {
+            await action(id);
+            await loadData();
+        } catch (err) {
+            alert(err.response?.data?.error || err.response?.data || err.message);
+        } finally {
+            setActionLoading(false);
+        }
+    };
+
     const handleUpdateView = async (newView) => {
         setActionLoading(true);
         try {
@@ -102,6 +128,79 @@ const AssetDetails = () => {
         }
     };
 
+    const handleSaveMetadata = async () => {
+        const changes = {};
+        if (editing.name !== asset.name) changes.name = editing.name;
+        if (editing.desc !== asset.description) changes.desc = editing.desc;
+        if (Object.keys(changes).length === 0) {
+            setEditing(null);
+            return;
+        }
+
+        setActionLoading(true);
+        try {
+            await updateAssetMetadata(id, changes);
+            setEditing(null);
+            await loadData();
+        } catch (err) {
+            alert(err.response?.data?.error || err.response?.data || err.message);
+        } finally {
+            setActionLoading(false);
+        }
+    };
+
+    // Attachments are added one transaction at a time so each shows up in the provenance history
+    const handleAddAttachments = async (e) => {
+        const files = Array.from(e.target.files);
+        e.target.value = '';
+        if (files.length === 0) return;
+
+        setActionLoading(true);
+        try {
+            const uploaded = await uploadManyToStorage(files);
+            for (const attachment of uploaded) {
+                await addAttachment(id, attachment);
+            }
+            await loadData();
+        } catch (err) {
+            alert("Attachment upload failed: " + (err.response?.data?.error || err.response?.data || err.message));
+        } finally {
+            setActionLoading(false);
+        }
+    };
+
+    const handleReplaceAttachment = async (index, e) => {
+        const file = e.target.files[0];
+        e.target.value = '';
+        if (!file) return;
+
+        setActionLoading(true);
+        try {
+            const uploaded = await uploadToStorage(file);
+            await replaceAttachment(id, index, asset.attachments[index].file_hash, uploaded);
+            await loadData();
+        } catch (err) {
+            alert("Replacement failed: " + (err.response?.data?.error || err.response?.data || err.message));
+        } finally {
+            setActionLoading(false);
+        }
+    };
+
+    const handleRemoveAttachment = async (index) => {
+        const attachment = asset.attachments[index];
+        if (!window.confirm(`Remove ${attachment.file_name} from this artifact? It remains in the provenance history.`)) return;
+
+        setActionLoading(true);
+        try {
+            await removeAttachment(id, index, attachment.file_hash);
+            await loadData();
+        } catch (err) {
+            alert("Removal failed: " + (err.response?.data?.error || err.response?.data || err.message));
+        } finally {
+            setActionLoading(false);
+        }
+    };
+
     const handleDelete = async () => {
         if (!window.confirm("Are you certain you wish to permanently delete this artifact from the ledger? This action is immutable.")) return;
 
@@ -111,7 +210,7 @@ const AssetDetails = () => {
             alert("Artifact successfully purged.");
             navigate(isFromAdmin ? "/admin/assets" : "/");
         } catch (err) {
-            alert("Deletion failed: " + (err.response?.data || err.message));
+            alert("Deletion failed: " + (err.response?.data?.error || err.response?.data || err.message));
         } finally {
             setActionLoading(false);
         }
@@ -124,7 +223,7 @@ const AssetDetails = () => {
             setBlockchainData(data);
             setShowBlockchainModal(true);
         } catch (err) {
-            alert("Verification Failed: " + (err.response?.data || err.message));
+            alert("Verification Failed: " + (err.response?.data?.error || err.response?.data || err.message));
         } finally {
             setActionLoading(false);
         }
@@ -214,6 +313,51 @@ const AssetDetails = () => {
                         </div>
                     )}
 
+                    {isOwner && !isPendingTransfer && asset.status === 'ACTIVE' && (
+                        <div className="space-y-3 mt-6">
+                            <label className="text-xs font-bold uppercase text-ink-900/40">Artifact Details</label>
+                            {editing ? (
+                                <>
+                                    <input
+                                        type="text"
+                                        className="w-full p-2 bg-parchment-50 border border-ink-900/20 rounded text-sm"
+                                        value={editing.name}
+                                        onChange={e => setEditing({ ...editing, name: e.target.value })}
+                                    />
+                                    <textarea
+                                        rows={3}
+                                        className="w-full p-2 bg-parchment-50 border border-ink-900/20 rounded text-sm"
+                                        value={editing.desc}
+                                        onChange={e => setEditing({ ...editing, desc: e.target.value })}
+                                    />
+                                    <div className="flex gap-2">
+                                        <button
+                                            onClick={handleSaveMetadata}
+                                            disabled={actionLoading || !editing.name.trim()}
+                                            className="flex-1 bg-ink-900 text-white py-2 rounded hover:bg-ink-800 disabled:opacity-50 text-sm font-bold transition-colors"
+                                        >
+                                            Save
+                                        </button>
+                                        <button
+                                            onClick={() => setEditing(null)}
+                                            disabled={actionLoading}
+                                            className="flex-1 py-2 rounded border border-ink-900/20 text-ink-900 hover:bg-parchment-100 text-sm font-bold transition-colors"
+                                        >
+                                            Cancel
+                                        </button>
+                                    </div>
+                                </>
+                            ) : (
+                                <button
+                                    onClick={() => setEditing({ name: asset.name || '', desc: asset.description || '' })}
+                                    className="w-full py-2 rounded border border-ink-900/20 text-ink-900 hover:bg-parchment-100 font-bold text-sm transition-colors"
+                                >
+                                    Edit Details
+                                </button>
+                            )}
+                        </div>
+                    )}
+
                     {isPendingTransfer && (
                         <div className="bg-amber-50 border border-amber-200 p-4 rounded-lg">
                             <div className="text-amber-800 font-bold text-sm mb-2">Transfer Pending</div>
@@ -224,13 +368,48 @@ const AssetDetails = () => {
                                 }
                             </div>
 
+                            {asset.transferExpiresAt && (
+                                <div className="text-xs text-ink-900/50 mb-3">
+                                    Expires: {new Date(asset.transferExpiresAt).toLocaleString()}
+                                </div>
+                            )}
+
                             {isProposedRecipient && (
+                                <div className="space-y-2">
+                                    <button
+                                        onClick={handleAccept}
+                                        disabled={actionLoading}
+                                        className="w-full flex justify-center items-center gap-2 bg-wax-red text-white py-2 rounded hover:bg-red-900 shadow-sm font-bold text-sm transition-colors"
+                                    >
+                                        <CheckCircle className="w-4 h-4" /> Accept Transfer
+                                    </button>
+                                    <button
+                                        onClick={() => handleRevertTransfer(rejectTransfer)}
+                                        disabled={actionLoading}
+                                        className="w-full py-2 rounded border border-ink-900/20 text-ink-900 hover:bg-parchment-100 font-bold text-sm transition-colors disabled:opacity-50"
+                                    >
+                                        Reject Transfer
+                                    </button>
+                                </div>
+                            )}
+
+                            {isOwner && (
+                                <button
+                                    onClick={() => handleRevertTransfer(cancelTransfer)}
+                                    disabled={actionLoading}
+                                    className="w-full py-2 rounded border border-ink-900/20 text-ink-900 hover:bg-parchment-100 font-bold text-sm transition-colors disabled:opacity-50"
+                                >
+                                    Cancel Transfer
+                                </button>
+                            )}
+
+                            {asset.transferExpiresAt && new Date(asset.transferExpiresAt) <= new Date() && (
                                 <button
-                                    onClick={handleAccept}
+                                    onClick={() => handleRevertTransfer(expireTransfer)}
                                     disabled={actionLoading}
-                                    className="w-full flex justify-center items-center gap-2 bg-wax-red text-white py-2 rounded hover:bg-red-900 shadow-sm font-bold text-sm transition-colors"
+                                    className="w-full mt-2 py-2 rounded border border-amber-300 text-amber-800 hover:bg-amber-100 font-bold text-sm transition-colors disabled:opacity-50"
                                 >
-                                    <CheckCircle className="w-4 h-4" /> Accept Transfer
+                                    Release Expired Proposal
                                 </button>
                             )}
                         </div>
@@ -274,52 +453,82 @@ const AssetDetails = () => {
                     )}
                 </div>
 
-                {/* Attachment Card */}
+                {/* Attachments Card */}
                 {
-                    asset.attachment && asset.attachment.file_name && (
+                    ((asset.attachments && asset.attachments.length > 0) || (isOwner && asset.status === 'ACTIVE')) && (
                         <div className="bg-white p-6 rounded-xl border border-ink-900/10 shadow-sm space-y-4">
                             <div className="flex items-center gap-2">
                                 <Paperclip className="text-bronze w-5 h-5" />
-                                <h3 className="font-serif font-bold text-lg text-ink-900">Supporting Document</h3>
+                                <h3 className="font-serif font-bold text-lg text-ink-900">Supporting Documents</h3>
                             </div>
 
-                            <div className="p-3 bg-parchment-50 rounded border border-ink-900/5 space-y-2">
-                                <div className="flex items-center gap-2">
-                                    <FileText size={14} className="text-ink-900/40" />
-                                    <span className="text-xs font-bold text-ink-900 truncate">{asset.attachment.file_name}</span>
-                                </div>
-                                <div className="grid grid-cols-2 gap-2 text-[10px] text-ink-900/60 uppercase font-bold tracking-tighter">
-                                    <div>Size: {(asset.attachment.file_size / 1024).toFixed(2)} KB</div>
-                                    <div>Type: {asset.attachment.storage_type}</div>
-                                </div>
-                                <div className="pt-1">
-                                    <div className="text-[8px] uppercase text-ink-900/30">IPFS CID</div>
-                                    <div className="text-[10px] font-mono text-bronze truncate">{asset.attachment.ipfs_cid}</div>
+                            {(asset.attachments || []).map((attachment, index) => (
+                                <div key={`${index}-${attachment.file_hash}`} className="space-y-2">
+                                    <div className="p-3 bg-parchment-50 rounded border border-ink-900/5 space-y-2">
+                                        <div className="flex items-center gap-2">
+                                            <FileText size={14} className="text-ink-900/40" />
+                                            <span className="text-xs font-bold text-ink-900 truncate">{attachment.file_name}</span>
+                                        </div>
+                                        <div className="grid grid-cols-2 gap-2 text-[10px] text-ink-900/60 uppercase font-bold tracking-tighter">
+                                            <div>Size: {(attachment.file_size / 1024).toFixed(2)} KB</div>
+                                            <div>Type: {attachment.storage_type}</div>
+                                        </div>
+                                        <div className="pt-1">
+                                            <div className="text-[8px] uppercase text-ink-900/30">IPFS CID</div>
+                                            <div className="text-[10px] font-mono text-bronze truncate">{attachment.ipfs_cid}</div>
+                                        </div>
+                                    </div>
+
+                                    <div className="flex gap-2 group/btns">
+                                        {attachmentUrls[index]?.view && (
+                                            <a
+                                                href={attachmentUrls[index].view}
+                                                target="_blank"
+                                                rel="noopener noreferrer"
+                                                className="flex-1 flex justify-center items-center gap-2 bg-parchment-200 text-ink-900 py-2 rounded-lg hover:bg-parchment-300 transition-all font-bold text-sm border border-ink-900/5 shadow-sm"
+                                                title="View in browser"
+                                            >
+                                                <Eye className="w-4 h-4" /> View
+                                            </a>
+                                        )}
+                                        {attachmentUrls[index]?.download && (
+                                            <a
+                                                href={attachmentUrls[index].download}
+                                                className="flex-1 flex justify-center items-center gap-2 bg-bronze text-white py-2 rounded-lg hover:bg-ink-900 transition-all font-bold text-sm shadow-md"
+                                                title="Download to device"
+                                            >
+                                                <Download className="w-4 h-4" /> Download
+                                            </a>
+                                        )}
+                                        {isOwner && asset.status === 'ACTIVE' && (
+                                            <>
+                                                <label
+                                                    className="flex items-center justify-center px-3 rounded-lg border border-ink-900/10 text-ink-900/60 hover:text-bronze hover:border-bronze cursor-pointer transition-all"
+                                                    title="Replace with a new version"
+                                                >
+                                                    <RefreshCw className="w-4 h-4" />
+                                                    <input type="file" className="hidden" disabled={actionLoading} onChange={(e) => handleReplaceAttachment(index, e)} />
+                                                </label>
+                                                <button
+                                                    onClick={() => handleRemoveAttachment(index)}
+                                                    disabled={actionLoading}
+                                                    className="flex items-center justify-center px-3 rounded-lg border border-ink-900/10 text-ink-900/60 hover:text-red-600 hover:border-red-600 transition-all"
+                                                    title="Remove attachment"
+                                                >
+                                                    <Trash2 className="w-4 h-4" />
+                                                </button>
+                                            </>
+                                        )}
+                                    </div>
                                 </div>
-                            </div>
+                            ))}
 
-                            <div className="flex gap-2 group/btns">
-                                {attachmentUrl && (
-                                    <a
-                                        href={attachmentUrl}
-                                        target="_blank"
-                                        rel="noopener noreferrer"
-                                        className="flex-1 flex justify-center items-center gap-2 bg-parchment-200 text-ink-900 py-3 rounded-lg hover:bg-parchment-300 transition-all font-bold text-sm border border-ink-900/5 shadow-sm"
-                                        title="View in browser"
-                                    >
-                                        <Eye className="w-4 h-4" /> View
-                                    </a>
-                                )}
-                                {attachmentDownloadUrl && (
-                                    <a
-                                        href={attachmentDownloadUrl}
-                                        className="flex-1 flex justify-center items-center gap-2 bg-bronze text-white py-3 rounded-lg hover:bg-ink-900 transition-all font-bold text-sm shadow-md"
-                                        title="Download to device"
-                                    >
-                                        <Download className="w-4 h-4" /> Download
-                                    </a>
-                                )}
-                            </div>
+                            {isOwner && asset.status === 'ACTIVE' && (
+                                <label className="flex justify-center items-center gap-2 w-full py-3 rounded-lg border border-dashed border-ink-900/20 text-ink-900/60 hover:border-bronze hover:text-bronze cursor-pointer transition-all font-bold text-sm">
+                                    <Upload className="w-4 h-4" /> {actionLoading ? 'Working...' : 'Add Documents'}
+                                    <input type="file" multiple className="hidden" disabled={actionLoading} onChange={handleAddAttachments} />
+                                </label>
+                            )}
                         </div>
                     )
                 }
@@ -7,8 +7,10 @@ const CreateAsset = () => {
     const navigate = useNavigate();
     const [loading, setLoading] = useState(false);
     const [uploading, setUploading] = useState(false);
+    // One key per submission: resubmitting after a network error replays instead of minting twice
+    const [idempotencyKey, setIdempotencyKey] = useState(() => crypto.randomUUID());
     const [form, setForm] = useState({
-        id: `artifact_${Date.now()}`,
+        id: '', // Left empty, the ledger generates a collision-free ID
         name: '',
         desc: '',
         image_url: '', // MinIO path
@@ -82,10 +84,14 @@ const CreateAsset = () => {
         }
         setLoading(true);
         try {
-            await createAsset(form);
+            await createAsset(form, idempotencyKey);
             navigate('/');
         } catch (err) {
-            alert("Failed to commit artifact: " + err.message);
+            // The server answered, so the outcome is final; edits after this are a new submission
+            if (err.response && err.response.status < 500 && err.response.data?.code !== 'REQUEST_IN_PROGRESS') {
+                setIdempotencyKey(crypto.randomUUID());
+            }
+            alert("Failed to commit artifact: " + (err.response?.data?.error || err.message));
         } finally {
             setLoading(false);
         }
@@ -106,7 +112,7 @@ const CreateAsset = () => {
             <form onSubmit={handleSubmit} className="space-y-8">
                 <div className="grid grid-cols-1 md:grid-cols-2 gap-8">
                     <div className="space-y-6">
-                        {/* ID (Readonly or Generated) */}
+                        {/* ID (Optional, generated by the ledger when empty) */}
                         <div>
                             <label className="block text-[10px] uppercase font-bold text-ink-900/40 mb-2 tracking-widest">Registry Identifier</label>
                             <input
@@ -114,6 +120,7 @@ const CreateAsset = () => {
                                 value={form.id}
                                 onChange={e => setForm({ ...form, id: e.target.value })}
                                 className="w-full p-2.5 bg-parchment-100 border border-ink-900/10 rounded font-mono text-xs text-ink-900/60 outline-none"
+                                placeholder="Generated on the ledger"
                             />
                         </div>
 
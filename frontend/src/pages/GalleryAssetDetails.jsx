@@ -10,8 +10,7 @@ const GalleryAssetDetails = () => {
     const [history, setHistory] = useState([]);
     const [loading, setLoading] = useState(true);
     const [displayUrl, setDisplayUrl] = useState('');
-    const [attachmentUrl, setAttachmentUrl] = useState(''); // View URL
-    const [attachmentDownloadUrl, setAttachmentDownloadUrl] = useState('');
+    const [attachmentUrls, setAttachmentUrls] = useState([]); // { view, download } per attachment
 
     useEffect(() => {
         loadData();
@@ -35,16 +34,19 @@ const GalleryAssetDetails = () => {
             }
 
             // MinIO First Attachment Resolution (View & Download)
-            if (a.attachment?.storage_path) {
+            const urls = await Promise.all((a.attachments || []).map(async (attachment) => {
+                if (!attachment.storage_path) return {};
                 try {
-                    const viewUrl = await fetchStorageURL(a.attachment.storage_path, false);
-                    const downloadUrl = await fetchStorageURL(a.attachment.storage_path, true);
-                    setAttachmentUrl(viewUrl);
-                    setAttachmentDownloadUrl(downloadUrl);
+                    return {
+                        view: await fetchStorageURL(attachment.storage_path, false),
+                        download: await fetchStorageURL(attachment.storage_path, true),
+                    };
                 } catch (e) {
                     console.error("Failed to fetch attachment URLs", e);
+                    return {};
                 }
-            }
+            }));
+            setAttachmentUrls(urls);
         } catch (err) {
             console.error("Error loading gallery artifact", err);
         } finally {
@@ -109,46 +111,50 @@ const GalleryAssetDetails = () => {
                             )}
 
                             {/* Attachment Section */}
-                            {asset.attachment && asset.attachment.file_name && (
+                            {asset.attachments && asset.attachments.length > 0 && (
                                 <div className="bg-white p-6 rounded-xl border border-ink-900/10 shadow-sm space-y-4">
                                     <div className="flex items-center gap-2">
                                         <Paperclip className="text-bronze w-5 h-5" />
                                         <h4 className="font-serif font-bold text-md text-ink-900">Supporting Evidence</h4>
                                     </div>
 
-                                    <div className="p-3 bg-parchment-50 rounded border border-ink-900/5 space-y-2">
-                                        <div className="flex items-center gap-2">
-                                            <FileText size={14} className="text-ink-900/40" />
-                                            <span className="text-xs font-bold text-ink-900 truncate">{asset.attachment.file_name}</span>
-                                        </div>
-                                        <div className="grid grid-cols-2 gap-2 text-[10px] text-ink-900/60 uppercase font-bold tracking-tighter">
-                                            <div>Size: {(asset.attachment.file_size / 1024).toFixed(2)} KB</div>
-                                            <div>Type: {asset.attachment.storage_type}</div>
+                                    {asset.attachments.map((attachment, index) => (
+                                        <div key={`${index}-${attachment.file_hash}`} className="space-y-2">
+                                            <div className="p-3 bg-parchment-50 rounded border border-ink-900/5 space-y-2">
+                                                <div className="flex items-center gap-2">
+                                                    <FileText size={14} className="text-ink-900/40" />
+                                                    <span className="text-xs font-bold text-ink-900 truncate">{attachment.file_name}</span>
+                                                </div>
+                                                <div className="grid grid-cols-2 gap-2 text-[10px] text-ink-900/60 uppercase font-bold tracking-tighter">
+                                                    <div>Size: {(attachment.file_size / 1024).toFixed(2)} KB</div>
+                                                    <div>Type: {attachment.storage_type}</div>
+                                                </div>
+                                            </div>
+
+                                            <div className="flex gap-2">
+                                                {attachmentUrls[index]?.view && (
+                                                    <a
+                                                        href={attachmentUrls[index].view}
+                                                        target="_blank"
+                                                        rel="noopener noreferrer"
+                                                        className="flex-1 flex justify-center items-center gap-2 bg-parchment-200 text-ink-900 py-3 rounded-lg hover:bg-parchment-300 transition-all font-bold text-sm border border-ink-900/5 shadow-sm"
+                                                        title="View in browser"
+                                                    >
+                                                        <Eye className="w-4 h-4" /> View
+                                                    </a>
+                                                )}
+                                                {attachmentUrls[index]?.download && (
+                                                    <a
+                                                        href={attachmentUrls[index].download}
+                                                        className="flex-1 flex justify-center items-center gap-2 bg-wax-red text-white py-3 rounded-lg hover:bg-ink-900 transition-all font-bold text-sm shadow-md"
+                                                        title="Download to device"
+                                                    >
+                                                        <Download className="w-4 h-4" /> Download
+                                                    </a>
+                                                )}
+                                            </div>
                                         </div>
-                                    </div>
-
-                                    <div className="flex gap-2">
-                                        {attachmentUrl && (
-                                            <a
-                                                href={attachmentUrl}
-                                                target="_blank"
-                                                rel="noopener noreferrer"
-                                                className="flex-1 flex justify-center items-center gap-2 bg-parchment-200 text-ink-900 py-3 rounded-lg hover:bg-parchment-300 transition-all font-bold text-sm border border-ink-900/5 shadow-sm"
-                                                title="View in browser"
-                                            >
-                                                <Eye className="w-4 h-4" /> View
-                                            </a>
-                                        )}
-                                        {attachmentDownloadUrl && (
-                                            <a
-                                                href={attachmentDownloadUrl}
-                                                className="flex-1 flex justify-center items-center gap-2 bg-wax-red text-white py-3 rounded-lg hover:bg-ink-900 transition-all font-bold text-sm shadow-md"
-                                                title="Download to device"
-                                            >
-                                                <Download className="w-4 h-4" /> Download
-                                            </a>
-                                        )}
-                                    </div>
+                                    ))}
                                 </div>
                             )}
                         </div>
@@ -27,8 +27,8 @@ cat <<JSON > metadata.json
 }
 JSON
 
-# Package it
-tar cfz code.tar.gz connection.json
+# Package it (CouchDB indexes ship in META-INF so the peer can deploy them)
+tar cfz code.tar.gz connection.json -C ${CC_SRC_PATH} META-INF
 tar cfz ${CC_NAME}.tar.gz metadata.json code.tar.gz
 
 # Cleanup temp files